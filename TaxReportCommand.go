@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/lots"
+	"github.com/PombeirP/wallet-balance/walletbalance"
+)
+
+// longTermHoldingPeriod is the holding period beyond which a gain is considered long-term
+const longTermHoldingPeriod = 365 * 24 * time.Hour
+
+// runTaxReportCommand exports the realized gains of the disposals within a tax year as CSV to `output`, or to the file set with -output,
+// and returns the exit code. Errors are reported to the standard error, so that they don't end up in the CSV
+func runTaxReportCommand(output io.Writer, args []string) int {
+	flags := flag.NewFlagSet("tax-report", flag.ContinueOnError)
+	year := flags.Int("year", time.Now().Year()-1, "tax year to report")
+	yearStart := flags.String("year-start", "01-01", "first day of the tax year (MM-DD), e.g. 04-06 for the UK")
+	methodName := flags.String("method", "", "lot matching method: fifo, lifo or average (defaults to the cost_basis.method of each entry)")
+	outputPath := flags.String("output", "", "CSV file to write (defaults to the standard output)")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	method := lots.Method("")
+	if *methodName != "" {
+		var err error
+		if method, err = lots.ParseMethod(*methodName); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 2
+		}
+	}
+
+	start, err := time.Parse(walletbalance.DateLayout, fmt.Sprintf("%04d-%s", *year, *yearStart))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 2
+	}
+	end := start.AddDate(1, 0, 0)
+
+	currenciesConfig, err := loadConfig()
+	if err != nil {
+		slog.Error("could not load configuration", "path", configPath, "error", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	client := walletbalance.NewClient(walletbalance.WithHTTPClient(newHTTPClient()))
+	disposals, errs := client.FetchDisposals(ctx, currenciesConfig, method, start, end)
+	for _, description := range describeEntryErrors(currenciesConfig, errs) {
+		fmt.Fprintln(os.Stderr, description)
+	}
+
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+
+	if err := writeDisposalsCSV(output, disposals); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+
+	// The disposals of the currencies reported above are missing from the export, so it must not be mistaken for a complete one
+	if len(errs) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+// writeDisposalsCSV writes one CSV row per disposal, with amounts in USD
+func writeDisposalsCSV(writer io.Writer, disposals []*walletbalance.TaxableDisposal) error {
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Write([]string{"currency", "acquired", "disposed", "amount", "proceeds_usd", "cost_basis_usd", "gain_usd", "holding_period_days", "term"})
+
+	formatAmount := func(amount float64, precision int) string {
+		return strconv.FormatFloat(amount, 'f', precision, 64)
+	}
+
+	for _, disposal := range disposals {
+		acquired, holdingPeriodDays, term := "", "", "unknown"
+		if !disposal.Unmatched {
+			acquired = disposal.Acquired.UTC().Format(walletbalance.DateLayout)
+			holdingPeriodDays = strconv.Itoa(int(disposal.HoldingPeriod() / (24 * time.Hour)))
+			term = "short"
+			if disposal.HoldingPeriod() > longTermHoldingPeriod {
+				term = "long"
+			}
+		}
+
+		csvWriter.Write([]string{
+			string(disposal.Symbol),
+			acquired,
+			disposal.Disposed.UTC().Format(walletbalance.DateLayout),
+			formatAmount(disposal.Amount, 8),
+			formatAmount(disposal.Proceeds, 2),
+			formatAmount(disposal.CostBasis, 2),
+			formatAmount(disposal.Gain(), 2),
+			holdingPeriodDays,
+			term,
+		})
+	}
+
+	csvWriter.Flush()
+
+	return csvWriter.Error()
+}
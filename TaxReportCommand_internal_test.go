@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/lots"
+	"github.com/PombeirP/wallet-balance/walletbalance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDisposalsCSV(t *testing.T) {
+	ledger := lots.NewLedger(lots.FIFO)
+	ledger.Acquire(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), 1., 400.)
+	ledger.Acquire(time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC), 1., 2500.)
+	ledger.Dispose(time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC), 2.5, 10000.)
+
+	var disposals []*walletbalance.TaxableDisposal
+	for _, disposal := range ledger.Disposals() {
+		disposals = append(disposals, &walletbalance.TaxableDisposal{Symbol: walletbalance.BTC, Disposal: disposal})
+	}
+
+	var output bytes.Buffer
+	require.NoError(t, writeDisposalsCSV(&output, disposals))
+	require.Equal(t, `currency,acquired,disposed,amount,proceeds_usd,cost_basis_usd,gain_usd,holding_period_days,term
+BTC,2016-01-01,2017-12-01,1.00000000,10000.00,400.00,9600.00,700,long
+BTC,2017-06-01,2017-12-01,1.00000000,10000.00,2500.00,7500.00,183,short
+BTC,,2017-12-01,0.50000000,5000.00,0.00,5000.00,,unknown
+`, output.String())
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+
+	"github.com/PombeirP/wallet-balance/walletbalance"
+	"github.com/fatih/color"
+)
+
+// runTransactionsCommand lists the recent transactions across all configured addresses to `output` and returns the exit code
+func runTransactionsCommand(output io.Writer, args []string) int {
+	flags := flag.NewFlagSet("transactions", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "maximum number of transactions to list")
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	slog.Debug("fetching transactions", "limit", *limit)
+
+	currenciesConfig, err := loadConfig()
+	if err != nil {
+		slog.Error("could not load configuration", "path", configPath, "error", err)
+		return 1
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	client := walletbalance.NewClient(walletbalance.WithHTTPClient(newHTTPClient()))
+	transactions, errs := client.FetchTransactions(ctx, currenciesConfig)
+
+	errorColor := color.New(color.FgHiRed).SprintFunc()
+	for _, description := range describeEntryErrors(currenciesConfig, errs) {
+		fmt.Fprintln(output, errorColor(description))
+	}
+
+	if len(transactions) > *limit {
+		transactions = transactions[:*limit]
+	}
+	printTransactions(output, transactions)
+
+	return 0
+}
+
+// printTransactions lists `transactions` to `output`, one per line
+func printTransactions(output io.Writer, transactions []*walletbalance.SymbolTransaction) {
+	inColor := color.New(color.FgHiGreen).SprintFunc()
+	outColor := color.New(color.FgHiRed).SprintFunc()
+	for _, tx := range transactions {
+		amount := tx.NetAmount()
+		amountString := fmt.Sprintf("%+14.8f", amount)
+		if amount >= 0 {
+			amountString = inColor(amountString)
+		} else {
+			amountString = outColor(amountString)
+		}
+
+		confirmations := "unconfirmed"
+		if tx.Confirmations > 0 {
+			confirmations = fmt.Sprintf("%d conf.", tx.Confirmations)
+		}
+
+		fmt.Fprintf(output, "%s %-5s %s (fee %.8f, %s) %s\n",
+			tx.Time.Local().Format("2006-01-02 15:04"),
+			tx.Label,
+			amountString,
+			tx.Fee,
+			confirmations,
+			tx.TxID)
+	}
+}
@@ -0,0 +1,84 @@
+package address
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// ErrInvalidChecksum is returned when the checksum of an address does not match its payload
+var ErrInvalidChecksum = errors.New("invalid checksum")
+
+// EncodeBase58Check encodes a version byte and payload in Base58Check, as used by legacy Bitcoin addresses
+func EncodeBase58Check(version byte, payload []byte) string {
+	data := append([]byte{version}, payload...)
+	data = append(data, base58Checksum(data)...)
+
+	number := new(big.Int).SetBytes(data)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	modulo := new(big.Int)
+
+	var encoded []byte
+	for number.Sign() > 0 {
+		number.DivMod(number, radix, modulo)
+		encoded = append(encoded, base58Alphabet[modulo.Int64()])
+	}
+	// Leading zero bytes are encoded as leading '1's
+	for _, b := range data {
+		if b != 0 {
+			break
+		}
+		encoded = append(encoded, base58Alphabet[0])
+	}
+
+	for i, j := 0, len(encoded)-1; i < j; i, j = i+1, j-1 {
+		encoded[i], encoded[j] = encoded[j], encoded[i]
+	}
+
+	return string(encoded)
+}
+
+// DecodeBase58Check decodes a Base58Check string into its version byte and payload, verifying its checksum
+func DecodeBase58Check(encoded string) (version byte, payload []byte, err error) {
+	number := new(big.Int)
+	radix := big.NewInt(int64(len(base58Alphabet)))
+	for _, char := range encoded {
+		index := bytes.IndexRune([]byte(base58Alphabet), char)
+		if index < 0 {
+			return 0, nil, errors.New("invalid base58 character")
+		}
+		number.Mul(number, radix)
+		number.Add(number, big.NewInt(int64(index)))
+	}
+
+	data := number.Bytes()
+	for _, char := range encoded {
+		if char != rune(base58Alphabet[0]) {
+			break
+		}
+		data = append([]byte{0}, data...)
+	}
+
+	if len(data) < 5 {
+		return 0, nil, errors.New("base58 data too short")
+	}
+
+	checksum := data[len(data)-4:]
+	data = data[:len(data)-4]
+	if !bytes.Equal(checksum, base58Checksum(data)) {
+		return 0, nil, ErrInvalidChecksum
+	}
+
+	return data[0], data[1:], nil
+}
+
+// base58Checksum returns the first 4 bytes of the double SHA-256 hash of `data`
+func base58Checksum(data []byte) []byte {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+
+	return second[:4]
+}
@@ -0,0 +1,97 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Checksum constants of the bech32 (BIP-173) and bech32m (BIP-350) encodings
+const (
+	bech32Constant  = 1
+	bech32mConstant = 0x2bc830a3
+)
+
+// DecodeSegWitAddress decodes a bech32/bech32m segregated witness address with human-readable part `hrp` into its witness version and program
+func DecodeSegWitAddress(hrp string, address string) (version byte, program []byte, err error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return 0, nil, errors.New("mixed case bech32 address")
+	}
+
+	address = strings.ToLower(address)
+	separator := strings.LastIndex(address, "1")
+	if separator < 1 || separator+7 > len(address) || len(address) > 90 {
+		return 0, nil, errors.New("invalid bech32 address length")
+	}
+	if address[:separator] != hrp {
+		return 0, nil, fmt.Errorf("unexpected bech32 prefix %s", address[:separator])
+	}
+
+	values := make([]byte, len(address)-separator-1)
+	for index, char := range address[separator+1:] {
+		value := strings.IndexRune(cashAddrCharset, char)
+		if value < 0 {
+			return 0, nil, fmt.Errorf("invalid bech32 character %q", char)
+		}
+		values[index] = byte(value)
+	}
+
+	constant := bech32Polymod(append(bech32HRPValues(hrp), values...))
+	if constant != bech32Constant && constant != bech32mConstant {
+		return 0, nil, ErrInvalidChecksum
+	}
+
+	values = values[:len(values)-6]
+	if len(values) == 0 {
+		return 0, nil, errors.New("empty witness program")
+	}
+	version = values[0]
+	if program, err = convertBits(values[1:], 5, 8, false); err != nil {
+		return 0, nil, err
+	}
+
+	switch {
+	case version > 16:
+		return 0, nil, fmt.Errorf("invalid witness version %d", version)
+	case len(program) < 2 || len(program) > 40:
+		return 0, nil, errors.New("invalid witness program length")
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return 0, nil, errors.New("invalid witness program length")
+	case version == 0 && constant != bech32Constant, version != 0 && constant != bech32mConstant:
+		return 0, nil, errors.New("invalid checksum variant for witness version")
+	}
+
+	return version, program, nil
+}
+
+// bech32HRPValues expands the human-readable part of a bech32 string for checksum computation
+func bech32HRPValues(hrp string) []byte {
+	values := make([]byte, 0, len(hrp)*2+1)
+	for _, char := range []byte(hrp) {
+		values = append(values, char>>5)
+	}
+	values = append(values, 0)
+	for _, char := range []byte(hrp) {
+		values = append(values, char&0x1f)
+	}
+
+	return values
+}
+
+// bech32Polymod computes the bech32 BCH checksum of `values`
+func bech32Polymod(values []byte) uint32 {
+	generators := []uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	checksum := uint32(1)
+	for _, value := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(value)
+		for index, generator := range generators {
+			if (top>>uint(index))&1 == 1 {
+				checksum ^= generator
+			}
+		}
+	}
+
+	return checksum
+}
@@ -0,0 +1,221 @@
+package address
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// CashAddrPrefix is the prefix of Bitcoin Cash mainnet CashAddr addresses
+	CashAddrPrefix = "bitcoincash"
+
+	cashAddrCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	// Version bytes of legacy Bitcoin Cash mainnet addresses
+	legacyP2PKHVersion = 0x00
+	legacyP2SHVersion  = 0x05
+
+	// Type bits of the CashAddr version byte
+	cashAddrP2PKHType = 0
+	cashAddrP2SHType  = 1
+)
+
+// AddressType identifies the kind of script an address pays to
+type AddressType int
+
+const (
+	// P2PKH denotes a pay-to-public-key-hash address
+	P2PKH AddressType = iota
+	// P2SH denotes a pay-to-script-hash address
+	P2SH
+)
+
+// IsCashAddr returns true if `address` looks like a CashAddr rather than a legacy address, with or without prefix
+func IsCashAddr(address string) bool {
+	lower := strings.ToLower(address)
+
+	return strings.Contains(lower, ":") || strings.HasPrefix(lower, "q") || strings.HasPrefix(lower, "p")
+}
+
+// DecodeCashAddr decodes a Bitcoin Cash CashAddr address (with or without the `bitcoincash:` prefix) into its type and hash
+func DecodeCashAddr(address string) (addressType AddressType, hash []byte, err error) {
+	if strings.ToLower(address) != address && strings.ToUpper(address) != address {
+		return 0, nil, errors.New("mixed case CashAddr")
+	}
+
+	address = strings.ToLower(address)
+	prefix, payload := CashAddrPrefix, address
+	if separator := strings.LastIndex(address, ":"); separator >= 0 {
+		prefix, payload = address[:separator], address[separator+1:]
+	}
+	if prefix != CashAddrPrefix {
+		return 0, nil, fmt.Errorf("unsupported CashAddr prefix %s", prefix)
+	}
+
+	values := make([]byte, len(payload))
+	for index, char := range payload {
+		value := strings.IndexRune(cashAddrCharset, char)
+		if value < 0 {
+			return 0, nil, fmt.Errorf("invalid CashAddr character %q", char)
+		}
+		values[index] = byte(value)
+	}
+	if len(values) < 8 {
+		return 0, nil, errors.New("CashAddr too short")
+	}
+	if cashAddrPolymod(append(cashAddrPrefixValues(prefix), values...)) != 0 {
+		return 0, nil, ErrInvalidChecksum
+	}
+
+	data, err := convertBits(values[:len(values)-8], 5, 8, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(data) != 21 || data[0]&0x07 != 0 {
+		return 0, nil, errors.New("unsupported CashAddr hash size")
+	}
+
+	switch data[0] >> 3 {
+	case cashAddrP2PKHType:
+		addressType = P2PKH
+	case cashAddrP2SHType:
+		addressType = P2SH
+	default:
+		return 0, nil, fmt.Errorf("unsupported CashAddr type %d", data[0]>>3)
+	}
+
+	return addressType, data[1:], nil
+}
+
+// EncodeCashAddr encodes a 160-bit hash as a Bitcoin Cash CashAddr address, including the `bitcoincash:` prefix
+func EncodeCashAddr(addressType AddressType, hash []byte) (string, error) {
+	if len(hash) != 20 {
+		return "", errors.New("only 160-bit hashes are supported")
+	}
+
+	versionByte := byte(cashAddrP2PKHType << 3)
+	if addressType == P2SH {
+		versionByte = cashAddrP2SHType << 3
+	}
+
+	values, err := convertBits(append([]byte{versionByte}, hash...), 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	checksumInput := append(cashAddrPrefixValues(CashAddrPrefix), values...)
+	checksumInput = append(checksumInput, make([]byte, 8)...)
+	checksum := cashAddrPolymod(checksumInput)
+	for index := 0; index < 8; index++ {
+		values = append(values, byte(checksum>>uint(5*(7-index)))&0x1f)
+	}
+
+	var encoded strings.Builder
+	encoded.WriteString(CashAddrPrefix + ":")
+	for _, value := range values {
+		encoded.WriteByte(cashAddrCharset[value])
+	}
+
+	return encoded.String(), nil
+}
+
+// ToCashAddr converts a legacy or CashAddr Bitcoin Cash address to its canonical CashAddr form, validating it
+func ToCashAddr(address string) (string, error) {
+	addressType, hash, err := decodeBitcoinCashAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeCashAddr(addressType, hash)
+}
+
+// ToLegacy converts a CashAddr or legacy Bitcoin Cash address to its legacy Base58Check form, validating it
+func ToLegacy(address string) (string, error) {
+	addressType, hash, err := decodeBitcoinCashAddress(address)
+	if err != nil {
+		return "", err
+	}
+
+	version := byte(legacyP2PKHVersion)
+	if addressType == P2SH {
+		version = legacyP2SHVersion
+	}
+
+	return EncodeBase58Check(version, hash), nil
+}
+
+// decodeBitcoinCashAddress decodes a Bitcoin Cash address in either CashAddr or legacy format
+func decodeBitcoinCashAddress(address string) (AddressType, []byte, error) {
+	if IsCashAddr(address) {
+		return DecodeCashAddr(address)
+	}
+
+	version, hash, err := DecodeBase58Check(address)
+	switch {
+	case err != nil:
+		return 0, nil, err
+	case len(hash) != 20:
+		return 0, nil, errors.New("invalid legacy address length")
+	case version == legacyP2PKHVersion:
+		return P2PKH, hash, nil
+	case version == legacyP2SHVersion:
+		return P2SH, hash, nil
+	default:
+		return 0, nil, fmt.Errorf("unsupported legacy address version %d", version)
+	}
+}
+
+// cashAddrPrefixValues returns the lower 5 bits of each prefix character followed by the separator value
+func cashAddrPrefixValues(prefix string) []byte {
+	values := make([]byte, 0, len(prefix)+1)
+	for _, char := range []byte(prefix) {
+		values = append(values, char&0x1f)
+	}
+
+	return append(values, 0)
+}
+
+// cashAddrPolymod computes the CashAddr BCH checksum of `values`
+func cashAddrPolymod(values []byte) uint64 {
+	generators := []uint64{0x98f2bc8e61, 0x79b76d99e2, 0xf33e5fb3c4, 0xae2eabe2a8, 0x1e4f43e470}
+
+	checksum := uint64(1)
+	for _, value := range values {
+		top := checksum >> 35
+		checksum = ((checksum & 0x07ffffffff) << 5) ^ uint64(value)
+		for index, generator := range generators {
+			if (top>>uint(index))&1 == 1 {
+				checksum ^= generator
+			}
+		}
+	}
+
+	return checksum ^ 1
+}
+
+// convertBits regroups `data` from `fromBits`-bit to `toBits`-bit values
+func convertBits(data []byte, fromBits, toBits uint, pad bool) ([]byte, error) {
+	var accumulator, bits uint
+	maxValue := uint(1)<<toBits - 1
+
+	var converted []byte
+	for _, value := range data {
+		accumulator = accumulator<<fromBits | uint(value)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			converted = append(converted, byte(accumulator>>bits&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			converted = append(converted, byte(accumulator<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || accumulator<<(toBits-bits)&maxValue != 0 {
+		return nil, errors.New("invalid padding")
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,60 @@
+package address_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/address"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCashAddrLegacyConversion(t *testing.T) {
+	cases := []struct {
+		legacy   string
+		cashAddr string
+	}{
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+		{"1KXrWXciRDZUpQwQmuM1DbwsKDLYAYsVLR", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"},
+		{"16w1D5WRVKJuZUsSRzdLp9w3YGcgoxDXb", "bitcoincash:qqq3728yw0y47sqn6l2na30mcw6zm78dzqre909m2r"},
+		{"3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq"},
+		{"3LDsS579y7sruadqu11beEJoTjdFiFCdX4", "bitcoincash:pr95sy3j9xwd2ap32xkykttr4cvcu7as4yc93ky28e"},
+	}
+
+	for _, testCase := range cases {
+		cashAddr, err := address.ToCashAddr(testCase.legacy)
+		require.NoError(t, err, testCase.legacy)
+		require.Equal(t, testCase.cashAddr, cashAddr)
+
+		legacy, err := address.ToLegacy(testCase.cashAddr)
+		require.NoError(t, err, testCase.cashAddr)
+		require.Equal(t, testCase.legacy, legacy)
+
+		// The prefix is optional, and upper-case addresses are valid
+		legacy, err = address.ToLegacy(testCase.cashAddr[len(address.CashAddrPrefix)+1:])
+		require.NoError(t, err, testCase.cashAddr)
+		require.Equal(t, testCase.legacy, legacy)
+
+		cashAddr, err = address.ToCashAddr(strings.ToUpper(testCase.cashAddr))
+		require.NoError(t, err, testCase.cashAddr)
+		require.Equal(t, testCase.cashAddr, cashAddr)
+	}
+}
+
+func TestCashAddrValidation(t *testing.T) {
+	cases := []struct {
+		address              string
+		expectedErrorMessage string
+	}{
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6c", "invalid checksum"},
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6A", "mixed case CashAddr"},
+		{"bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "unsupported CashAddr prefix bchtest"},
+		{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6i", `invalid CashAddr character 'i'`},
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggv", "invalid checksum"},
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVgg0", "invalid base58 character"},
+	}
+
+	for _, testCase := range cases {
+		_, err := address.ToCashAddr(testCase.address)
+		require.EqualError(t, err, testCase.expectedErrorMessage, testCase.address)
+	}
+}
@@ -0,0 +1,26 @@
+package address
+
+import "strings"
+
+// extendedPublicKeyPrefixes lists the prefixes of the mainnet and testnet extended public keys of the supported networks
+var extendedPublicKeyPrefixes = []string{
+	"xpub", "ypub", "zpub", "Ypub", "Zpub", // BTC and BCH
+	"tpub", "upub", "vpub", // BTC testnet
+	"Ltub", "Mtub", // LTC
+	"drkp", // DASH
+	"dgub", // DOGE
+}
+
+// IsExtendedPublicKey returns true if `address` is an extended public key or an output descriptor rather than a single address
+func IsExtendedPublicKey(address string) bool {
+	if strings.Contains(address, "(") {
+		return true
+	}
+	for _, prefix := range extendedPublicKeyPrefixes {
+		if strings.HasPrefix(address, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
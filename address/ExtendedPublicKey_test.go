@@ -0,0 +1,38 @@
+package address_test
+
+import (
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/address"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsExtendedPublicKey(t *testing.T) {
+	cases := []struct {
+		address  string
+		expected bool
+	}{
+		{"xpub6CUGRUonZSQ4TWtTMmzXdrXDtypWKiKrhko4egpiMZbpiaQL2jkwSB1icqYh2cfDfVxdx4df189oLKnC5fSwqPfgyP3hooxujYzAu3fDVmz", true},
+		{"ypub6Ww3ibxVfGzLrAH1PNcjyAWenMTbbAosGNB6VvmSEgytSER9azLDWCxoJwW7Ke7icmizBMXrzBx9979FfaHxHcrArf3zbeJJJUZPf663zsP", true},
+		{"zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs", true},
+		{"Ypub6hKLNDhSimv58SsXMbZtvuDBTgaF9mLRgMvdA4u4Di6TJWr4vz2TXvdDp6pX3UgxKGRN1YAhEJTrgM3CC5qvjAn6Q6YsXypxHhSiuKx9n7L", true},
+		{"Zpub72d8CAWRcqdsJUkgy2uyjcbgdLv3GZDU6pwvwTL8Qzh3EbDR9mRYb9zwKMDV7CLn6PuX3DYYz4ub2z9PYTuYZ6W5FbP1vjwYmvKe23xbaMK", true},
+		{"tpubDC8msFGeGuwnKG9Upg7DM2b4DaRqg3CUZa5g8v2SRQ6K4NSkxUgd7HsL2XVWbVm39yBA4LAxysQAm397zwQSQoQgewGiYZqrA9DsP4zbQ1M", true},
+		{"upub5EFU65HtV5TeiSHmZZm7FUffBGy8UKeqp7vw43jYbvZPpoVsgU93oac7Wk3u6moKegAEWtGNF8DehrnHtv21XXEMYRUocHqguyjknFHYfgY", true},
+		{"vpub5Y6cjg78GGuNLsaPhmYsiw4gYX3HoQiRBiSwDaBXKUafCt9bNwWQiitDk5VZ5BVxYnQdwoTyXSs2JHRPAgjAvtbBrf8ZhDYe2jWAqvZVnsc", true},
+		{"Ltub2SSUS19CirucWFod2ZsYA2J4v4U76YiCXHdcQttnoiy5aGanFHCPDBX7utfG6f95u1cUbZJNafmvzNCzZZJTw1EmyFoL8u1gJbGM8ipu491", true},
+		{"Mtub2rz9F1pkisRsSZX8sa4Ajon9GhPP6JymLgpuHqbYdU5JKFLBF7Qy8b1tZ3dccj2fefrAxfrPdVkpCxuWn3g72UctH2bvJRkp6iFmp8aLeRZ", true},
+		{"drkpRzPWNcH6ZFNXcFGjyjiL7k2xbGa1h3gsB6YrCiHhSKsmGwWJ8AtQk6yeAwjnvKLNFVGELCtr5b3pU1vtHLp4CqCzRKQvy2A8MXjqe4uE3M7", true},
+		{"dgub8kXBZ7ymNWy2S8Q3jNgVjFUm5ZJ3QLLaSTdAA89ukSv7Q6MSXwE14b7Nv6eDpE9JJXinTKc8LeLVu19uDPrm5uJuhpKNzV2kAgncwo6bNpP", true},
+		{"wpkh([d34db33f/84'/0'/0']xpub6ERApfZwUNrhLCkDtcHTcxd75RbzS1ed54G1LkBUHQVHQKqhMkhgbmJbZRkrgZw4koxb5JaHWkY4ALHY2grBGRjaDMzQLcgJvLJuZZvRcEL/0/*)", true},
+		{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", false},
+		{"bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy", false},
+		{"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", false},
+		{"LQ3B36Yv2rBTxdgAdYpU2UcEZsaNwXeATk", false},
+		{"0x5aeda56215b167893e80b4fe645ba6d5bab767de", false},
+	}
+
+	for _, testCase := range cases {
+		require.Equal(t, testCase.expected, address.IsExtendedPublicKey(testCase.address), testCase.address)
+	}
+}
@@ -0,0 +1,101 @@
+package address
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Network describes the address formats of a Bitcoin-derived crypto-currency
+type Network struct {
+	// PubKeyHashVersion is the version byte of legacy pay-to-public-key-hash addresses
+	PubKeyHashVersion byte
+	// ScriptHashVersions lists the version bytes of legacy pay-to-script-hash addresses
+	ScriptHashVersions []byte
+	// Bech32HRP is the human-readable part of segregated witness addresses, if the network supports them
+	Bech32HRP string
+	// CashAddr denotes that CashAddr addresses are accepted
+	CashAddr bool
+}
+
+// Networks maps ticker symbols to the mainnet address formats of the corresponding crypto-currency
+var Networks = map[string]*Network{
+	"BTC":  {PubKeyHashVersion: 0x00, ScriptHashVersions: []byte{0x05}, Bech32HRP: "bc"},
+	"BCH":  {PubKeyHashVersion: 0x00, ScriptHashVersions: []byte{0x05}, CashAddr: true},
+	"LTC":  {PubKeyHashVersion: 0x30, ScriptHashVersions: []byte{0x32, 0x05}, Bech32HRP: "ltc"},
+	"DASH": {PubKeyHashVersion: 0x4c, ScriptHashVersions: []byte{0x10}},
+	"DOGE": {PubKeyHashVersion: 0x1e, ScriptHashVersions: []byte{0x16}},
+}
+
+// ScriptPubKey returns the output script paying to `address`, validating it
+func (network *Network) ScriptPubKey(address string) ([]byte, error) {
+	if network.Bech32HRP != "" && strings.HasPrefix(strings.ToLower(address), network.Bech32HRP+"1") {
+		version, program, err := DecodeSegWitAddress(network.Bech32HRP, address)
+		if err != nil {
+			return nil, err
+		}
+
+		opCode := version
+		if version > 0 {
+			opCode = 0x50 + version // OP_1 to OP_16
+		}
+		return append([]byte{opCode, byte(len(program))}, program...), nil
+	}
+
+	if network.CashAddr && IsCashAddr(address) {
+		addressType, hash, err := DecodeCashAddr(address)
+		if err != nil {
+			return nil, err
+		}
+		if addressType == P2SH {
+			return payToScriptHash(hash), nil
+		}
+		return payToPubKeyHash(hash), nil
+	}
+
+	version, hash, err := DecodeBase58Check(address)
+	if err != nil {
+		return nil, err
+	}
+	if len(hash) != 20 {
+		return nil, fmt.Errorf("invalid legacy address length")
+	}
+	if version == network.PubKeyHashVersion {
+		return payToPubKeyHash(hash), nil
+	}
+	for _, scriptHashVersion := range network.ScriptHashVersions {
+		if version == scriptHashVersion {
+			return payToScriptHash(hash), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported legacy address version %d", version)
+}
+
+// ElectrumScriptHash returns the script hash identifying `address` in the Electrum protocol: the reversed SHA-256 hash of its output script, in hex
+func (network *Network) ElectrumScriptHash(address string) (string, error) {
+	script, err := network.ScriptPubKey(address)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(script)
+	for i, j := 0, len(hash)-1; i < j; i, j = i+1, j-1 {
+		hash[i], hash[j] = hash[j], hash[i]
+	}
+
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// payToPubKeyHash returns the OP_DUP OP_HASH160 <hash> OP_EQUALVERIFY OP_CHECKSIG script
+func payToPubKeyHash(hash []byte) []byte {
+	script := append([]byte{0x76, 0xa9, 0x14}, hash...)
+	return append(script, 0x88, 0xac)
+}
+
+// payToScriptHash returns the OP_HASH160 <hash> OP_EQUAL script
+func payToScriptHash(hash []byte) []byte {
+	script := append([]byte{0xa9, 0x14}, hash...)
+	return append(script, 0x87)
+}
@@ -0,0 +1,44 @@
+package address_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/address"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkScriptPubKey(t *testing.T) {
+	cases := []struct {
+		symbol               string
+		address              string
+		expectedScript       string
+		expectedErrorMessage string
+	}{
+		{"BTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac", ""},
+		{"BTC", "3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "a91476a04053bda0a88bda5177b86a15c3b29f55987387", ""},
+		{"BTC", "BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4", "0014751e76e8199196d454941c45d1b3a323f1433bd6", ""},
+		{"BTC", "bc1pw508d6qejxtdg4y5r3zarvary0c5xw7kw508d6qejxtdg4y5r3zarvary0c5xw7kt5nd6y",
+			"5128751e76e8199196d454941c45d1b3a323f1433bd6751e76e8199196d454941c45d1b3a323f1433bd6", ""},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kemeawh", "", "invalid checksum variant for witness version"},
+		{"BTC", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t5", "", "invalid checksum"},
+		{"BCH", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq", "a91476a04053bda0a88bda5177b86a15c3b29f55987387", ""},
+		{"LTC", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "", "unsupported legacy address version 0"},
+	}
+
+	for _, testCase := range cases {
+		script, err := address.Networks[testCase.symbol].ScriptPubKey(testCase.address)
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.address)
+		} else {
+			require.NoError(t, err, testCase.address)
+			require.Equal(t, testCase.expectedScript, hex.EncodeToString(script), testCase.address)
+		}
+	}
+}
+
+func TestNetworkElectrumScriptHash(t *testing.T) {
+	scriptHash, err := address.Networks["BTC"].ElectrumScriptHash("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa")
+	require.NoError(t, err)
+	require.Equal(t, "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", scriptHash)
+}
@@ -0,0 +1,2 @@
+// Package address provides encoding, decoding and validation of crypto-currency addresses
+package address
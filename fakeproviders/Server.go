@@ -0,0 +1,273 @@
+package fakeproviders
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Names of the emulated providers, matching the names of the fetchers providers
+const (
+	BlockchainInfo = "blockchain.info"
+	Etherscan      = "etherscan"
+	Cryptoid       = "cryptoid"
+)
+
+// Server emulates the APIs of several providers on a local HTTP server. The API of each provider is served under its base URL (see BaseURL)
+type Server struct {
+	server *httptest.Server
+
+	mutex       sync.Mutex
+	balances    map[string]map[string]float64
+	prices      map[string]float64
+	apiKeys     map[string]string
+	rateLimited map[string]int
+	unavailable map[string]bool
+	requests    map[string]int
+}
+
+// NewServer starts a Server. It must be closed with Close
+func NewServer() *Server {
+	server := &Server{
+		balances:    make(map[string]map[string]float64),
+		prices:      make(map[string]float64),
+		apiKeys:     make(map[string]string),
+		rateLimited: make(map[string]int),
+		unavailable: make(map[string]bool),
+		requests:    make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/"+BlockchainInfo+"/", server.handle(BlockchainInfo, server.serveBlockchainInfo))
+	mux.HandleFunc("/"+Etherscan+"/", server.handle(Etherscan, server.serveEtherscan))
+	mux.HandleFunc("/"+Cryptoid+"/", server.handle(Cryptoid, server.serveCryptoid))
+	server.server = httptest.NewServer(mux)
+
+	return server
+}
+
+// Close shuts the server down
+func (server *Server) Close() {
+	server.server.Close()
+}
+
+// BaseURL returns the URL to configure as the `base_url` option of `provider`
+func (server *Server) BaseURL(provider string) string {
+	return server.server.URL + "/" + provider
+}
+
+// SetBalance sets the balance of `address` on `provider`, in whole coins. Addresses without a balance are rejected as invalid
+func (server *Server) SetBalance(provider string, address string, balance float64) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.balances[provider] == nil {
+		server.balances[provider] = make(map[string]float64)
+	}
+	server.balances[provider][address] = balance
+}
+
+// SetPrice sets the USD price of the coin served by `provider`
+func (server *Server) SetPrice(provider string, price float64) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.prices[provider] = price
+}
+
+// RequireAPIKey makes `provider` reject the requests authenticated with another API key than `apiKey`
+func (server *Server) RequireAPIKey(provider string, apiKey string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.apiKeys[provider] = apiKey
+}
+
+// RateLimit makes `provider` reject its next `requests` requests as rate limited, the way the real provider does
+func (server *Server) RateLimit(provider string, requests int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.rateLimited[provider] = requests
+}
+
+// SetUnavailable makes `provider` answer all requests with 503 Service Unavailable, until called again with false
+func (server *Server) SetUnavailable(provider string, unavailable bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.unavailable[provider] = unavailable
+}
+
+// Requests returns the number of requests received by `provider`
+func (server *Server) Requests(provider string) int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.requests[provider]
+}
+
+// handle counts the requests to `provider` and applies the unavailability and rate limits set on it before calling `serve`
+func (server *Server) handle(provider string, serve func(w http.ResponseWriter, r *http.Request, path string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.requests[provider]++
+		unavailable := server.unavailable[provider]
+		rateLimited := server.rateLimited[provider] > 0
+		if rateLimited {
+			server.rateLimited[provider]--
+		}
+		server.mutex.Unlock()
+
+		switch {
+		case unavailable:
+			w.Header().Set("Retry-After", "0")
+			writeError(w, http.StatusServiceUnavailable, "Service Unavailable")
+		case rateLimited && provider == Etherscan:
+			// Etherscan reports rate limits in a successful response
+			writeJSON(w, etherscanResponse("0", "NOTOK", "Max rate limit reached"))
+		case rateLimited:
+			w.Header().Set("Retry-After", "0")
+			writeError(w, http.StatusTooManyRequests, "Too Many Requests")
+		default:
+			serve(w, r, strings.TrimPrefix(r.URL.Path, "/"+provider))
+		}
+	}
+}
+
+// balance returns the balance of `address` on `provider`, and whether the address is known
+func (server *Server) balance(provider string, address string) (float64, bool) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	balance, ok := server.balances[provider][address]
+	return balance, ok
+}
+
+// price returns the USD price of the coin served by `provider`
+func (server *Server) price(provider string) float64 {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return server.prices[provider]
+}
+
+// authorized returns true if `apiKey` is accepted by `provider`
+func (server *Server) authorized(provider string, apiKey string) bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	required, ok := server.apiKeys[provider]
+	return !ok || apiKey == required
+}
+
+// serveBlockchainInfo emulates the /q/addressbalance and /tobtc endpoints of https://blockchain.info/
+func (server *Server) serveBlockchainInfo(w http.ResponseWriter, r *http.Request, path string) {
+	switch {
+	case strings.HasPrefix(path, "/q/addressbalance/"):
+		var satoshis int64
+		for _, address := range strings.Split(strings.TrimPrefix(path, "/q/addressbalance/"), "|") {
+			balance, ok := server.balance(BlockchainInfo, address)
+			if !ok {
+				writeError(w, http.StatusBadRequest, "Checksum does not validate: invalid Bitcoin address")
+				return
+			}
+			satoshis += int64(balance*1e8 + .5)
+		}
+		fmt.Fprint(w, satoshis)
+	case path == "/tobtc":
+		price := server.price(BlockchainInfo)
+		if price == 0 || r.URL.Query().Get("currency") != "usd" {
+			writeError(w, http.StatusBadRequest, "Parameter <currency> with unsupported value")
+			return
+		}
+		fmt.Fprint(w, strconv.FormatFloat(1/price, 'f', -1, 64))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveEtherscan emulates the balancemulti and price actions of https://api.etherscan.io/
+func (server *Server) serveEtherscan(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	switch {
+	case path != "/api":
+		http.NotFound(w, r)
+	case query.Get("module") == "account" && query.Get("action") == "balancemulti":
+		type accountBalance struct {
+			Account string `json:"account"`
+			Balance string `json:"balance"`
+		}
+		var result []*accountBalance
+		for _, address := range strings.Split(query.Get("address"), ",") {
+			balance, ok := server.balance(Etherscan, address)
+			if !ok {
+				writeJSON(w, etherscanResponse("0", "NOTOK", "Error! Invalid address format"))
+				return
+			}
+			wei, _ := new(big.Float).Mul(big.NewFloat(balance), big.NewFloat(1e18)).Int(nil)
+			result = append(result, &accountBalance{address, wei.String()})
+		}
+		writeJSON(w, etherscanResponse("1", "OK", result))
+	case query.Get("module") == "stats" && strings.HasSuffix(query.Get("action"), "price"):
+		if !server.authorized(Etherscan, query.Get("apikey")) {
+			writeJSON(w, etherscanResponse("0", "NOTOK", "Invalid API Key"))
+			return
+		}
+		coin := strings.TrimSuffix(query.Get("action"), "price")
+		price := strconv.FormatFloat(server.price(Etherscan), 'f', -1, 64)
+		writeJSON(w, etherscanResponse("1", "OK", map[string]string{coin + "usd": price, coin + "usd_timestamp": "1514764800"}))
+	default:
+		writeJSON(w, etherscanResponse("0", "NOTOK", "Error! Missing Or invalid Module name"))
+	}
+}
+
+// serveCryptoid emulates the getbalance and ticker queries of https://chainz.cryptoid.info/
+func (server *Server) serveCryptoid(w http.ResponseWriter, r *http.Request, path string) {
+	query := r.URL.Query()
+	if !strings.HasSuffix(path, "/api.dws") {
+		http.NotFound(w, r)
+		return
+	}
+	if !server.authorized(Cryptoid, query.Get("key")) {
+		writeError(w, http.StatusUnauthorized, "Unauthorized: invalid API key")
+		return
+	}
+
+	switch q := query.Get("q"); {
+	case q == "getbalance":
+		balance, ok := server.balance(Cryptoid, query.Get("a"))
+		if !ok {
+			writeError(w, http.StatusBadRequest, "Invalid address")
+			return
+		}
+		fmt.Fprint(w, strconv.FormatFloat(balance, 'f', -1, 64))
+	case q == "ticker.usd":
+		fmt.Fprint(w, strconv.FormatFloat(server.price(Cryptoid), 'f', -1, 64))
+	default:
+		writeError(w, http.StatusBadRequest, "Unsupported query")
+	}
+}
+
+// etherscanResponse builds the envelope of an Etherscan API response
+func etherscanResponse(status string, message string, result interface{}) interface{} {
+	return map[string]interface{}{"status": status, "message": message, "result": result}
+}
+
+// writeError writes a plain text error response, without the trailing newline added by http.Error
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	fmt.Fprint(w, message)
+}
+
+// writeJSON writes `value` as a JSON response
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,2 @@
+// Package fakeproviders provides an in-process HTTP server emulating the blockchain.info, Etherscan and Cryptoid APIs, for integration tests
+package fakeproviders
@@ -0,0 +1,91 @@
+package fetchers
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentBatches is the maximum number of address batches fetched simultaneously from a provider
+const maxConcurrentBatches = 4
+
+// BatchError aggregates the errors of the failed batches of a query split into several address batches
+type BatchError struct {
+	// Errors holds the error of each failed batch
+	Errors []error
+	// BatchCount is the total number of batches of the query
+	BatchCount int
+}
+
+func (err *BatchError) Error() string {
+	messages := make([]string, len(err.Errors))
+	for index, batchErr := range err.Errors {
+		messages[index] = batchErr.Error()
+	}
+
+	return fmt.Sprintf("%d of %d address batches failed: %s", len(err.Errors), err.BatchCount, strings.Join(messages, "; "))
+}
+
+// splitAddresses splits `addresses` into batches of at most `batchSize` addresses. A non-positive `batchSize` yields a single batch
+func splitAddresses(addresses []string, batchSize int) [][]string {
+	if batchSize <= 0 || len(addresses) <= batchSize {
+		return [][]string{addresses}
+	}
+
+	batches := make([][]string, 0, (len(addresses)+batchSize-1)/batchSize)
+	for start := 0; start < len(addresses); start += batchSize {
+		end := start + batchSize
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+		batches = append(batches, addresses[start:end])
+	}
+
+	return batches
+}
+
+// fetchInBatches splits `addresses` into batches of at most `batchSize` addresses, fetches up to maxConcurrentBatches of them at once with `fetch`
+// and returns the sum of their results. The error of a single failed batch is returned as is, while several failures are reported as a *BatchError
+func fetchInBatches(addresses []string, batchSize int, fetch func(batch []string) (float64, error)) (total float64, err error) {
+	batches := splitAddresses(addresses, batchSize)
+	if len(batches) == 1 {
+		return fetch(batches[0])
+	}
+
+	logger().Debug("fetching address batches", "addresses", len(addresses), "batches", len(batches), "batch_size", batchSize)
+
+	results := make([]float64, len(batches))
+	errs := make([]error, len(batches))
+	slots := make(chan struct{}, maxConcurrentBatches)
+
+	var wg sync.WaitGroup
+	wg.Add(len(batches))
+	for index, batch := range batches {
+		slots <- struct{}{}
+		go func(index int, batch []string) {
+			defer wg.Done()
+			results[index], errs[index] = fetch(batch)
+			<-slots
+		}(index, batch)
+	}
+	wg.Wait()
+
+	var failures []error
+	for index, result := range results {
+		if errs[index] != nil {
+			failures = append(failures, errs[index])
+			continue
+		}
+		total += result
+	}
+
+	switch len(failures) {
+	case 0:
+	case 1:
+		err = failures[0]
+	default:
+		err = &BatchError{failures, len(batches)}
+	}
+
+	return
+}
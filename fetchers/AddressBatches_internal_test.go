@@ -0,0 +1,60 @@
+package fetchers
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchInBatches(t *testing.T) {
+	addresses := make([]string, 45)
+	for index := range addresses {
+		addresses[index] = fmt.Sprintf("a%d", index)
+	}
+
+	cases := []struct {
+		name                 string
+		batchSize            int
+		failingBatches       map[string]bool
+		expectedBatchCount   int32
+		expectedTotal        float64
+		expectedErrorMessage string
+	}{
+		{"single batch", 0, nil, 1, 45, ""},
+		{"batches of 20", 20, nil, 3, 45, ""},
+		{"batches of 1", 1, nil, 45, 45, ""},
+		{"one failed batch", 20, map[string]bool{"a20": true}, 3, 25, "batch a20 failed"},
+		{"several failed batches", 20, map[string]bool{"a0": true, "a40": true}, 3, 20, "2 of 3 address batches failed: batch a0 failed; batch a40 failed"},
+	}
+
+	for _, testCase := range cases {
+		var batchCount, running, maxRunning int32
+		total, err := fetchInBatches(addresses, testCase.batchSize, func(batch []string) (float64, error) {
+			atomic.AddInt32(&batchCount, 1)
+			if current := atomic.AddInt32(&running, 1); current > atomic.LoadInt32(&maxRunning) {
+				atomic.StoreInt32(&maxRunning, current)
+			}
+			defer atomic.AddInt32(&running, -1)
+
+			if testCase.batchSize > 0 {
+				require.True(t, len(batch) <= testCase.batchSize, testCase.name)
+			}
+			if testCase.failingBatches[batch[0]] {
+				return 0, errors.New("batch " + batch[0] + " failed")
+			}
+			return float64(len(batch)), nil
+		})
+
+		require.Equal(t, testCase.expectedBatchCount, batchCount, testCase.name)
+		require.True(t, maxRunning <= maxConcurrentBatches, testCase.name)
+		require.Equal(t, testCase.expectedTotal, total, testCase.name)
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+		}
+	}
+}
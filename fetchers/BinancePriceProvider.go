@@ -0,0 +1,148 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BinancePriceProviderName is the name under which BinancePriceProvider is registered
+const BinancePriceProviderName = "binance"
+
+// binanceQuoteAssets maps target currencies to the quote asset of the Binance trading pairs used to price them
+var binanceQuoteAssets = map[string]string{
+	"usd": "USDT",
+	"eur": "EUR",
+	"btc": "BTC",
+}
+
+func init() {
+	RegisterPriceProvider(BinancePriceProviderName, func(client HTTPClient) PriceProvider {
+		return NewBinancePriceProvider(client)
+	})
+}
+
+// binanceTickerPrice holds the last traded price of a trading pair returned by the ticker price API
+type binanceTickerPrice struct {
+	Symbol string `json:"symbol"`
+	Price  string `json:"price"`
+}
+
+// BinancePriceProvider fetches crypto-currency prices from the https://www.binance.com/ exchange ticker API
+type BinancePriceProvider struct {
+	apiFetcher JSONFetcher
+}
+
+// NewBinancePriceProvider creates an instance of BinancePriceProvider from an HTTP client instance
+func NewBinancePriceProvider(client HTTPClient) *BinancePriceProvider {
+	return &BinancePriceProvider{NewWebJSONFetcher(client)}
+}
+
+// PriceID returns the Binance base asset for `symbol`
+func (provider *BinancePriceProvider) PriceID(symbol string) string {
+	return strings.ToUpper(symbol)
+}
+
+// FetchPrices retrieves the last traded prices in `targetCurrency` of the base assets `ids` in a single call
+func (provider *BinancePriceProvider) FetchPrices(ids []string, targetCurrency string) (prices map[string]float64, err error) {
+	quoteAsset, ok := binanceQuoteAssets[strings.ToLower(targetCurrency)]
+	if !ok {
+		err = errorf(UnsupportedCurrencyError, "%s is not supported as target currency by %s", targetCurrency, BinancePriceProviderName)
+		return
+	}
+
+	pairs := make([]string, len(ids))
+	baseAssets := make(map[string]string, len(ids))
+	for index, id := range ids {
+		pairs[index] = id + quoteAsset
+		baseAssets[pairs[index]] = id
+	}
+
+	rawPairs, err := json.Marshal(pairs)
+	if err != nil {
+		return
+	}
+
+	var response []*binanceTickerPrice
+	url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/price?symbols=%s", url.QueryEscape(string(rawPairs)))
+	if err = provider.apiFetcher.Fetch(url, &response); err != nil {
+		// Binance rejects the whole request if any of the pairs isn't listed, so the pairs are then requested one by one to leave out only the unlisted ones
+		if ErrorKindOf(err) == UnsupportedCurrencyError && len(pairs) > 1 {
+			return provider.fetchPricesOneByOne(pairs, baseAssets)
+		}
+		return
+	}
+
+	prices = make(map[string]float64, len(response))
+	for _, ticker := range response {
+		price, errParse := strconv.ParseFloat(ticker.Price, 64)
+		if errParse != nil {
+			return nil, newError(MalformedResponseError, url, errParse)
+		}
+		prices[baseAssets[ticker.Symbol]] = price
+	}
+
+	return
+}
+
+// fetchPricesOneByOne retrieves the last traded price of each of the trading pairs `pairs` with a call per pair, skipping the pairs which aren't listed
+func (provider *BinancePriceProvider) fetchPricesOneByOne(pairs []string, baseAssets map[string]string) (prices map[string]float64, err error) {
+	prices = make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		var ticker binanceTickerPrice
+		url := fmt.Sprintf("https://api.binance.com/api/v3/ticker/price?symbol=%s", pair)
+		if err = provider.apiFetcher.Fetch(url, &ticker); err != nil {
+			if ErrorKindOf(err) == UnsupportedCurrencyError {
+				continue
+			}
+			return nil, err
+		}
+
+		price, errParse := strconv.ParseFloat(ticker.Price, 64)
+		if errParse != nil {
+			return nil, newError(MalformedResponseError, url, errParse)
+		}
+		prices[baseAssets[pair]] = price
+	}
+
+	return prices, nil
+}
+
+// FetchHistoricalPrice retrieves the daily closing price in `targetCurrency` of the base asset `id` on the date of `at`
+func (provider *BinancePriceProvider) FetchHistoricalPrice(id string, targetCurrency string, at time.Time) (price float64, err error) {
+	quoteAsset, ok := binanceQuoteAssets[strings.ToLower(targetCurrency)]
+	if !ok {
+		err = errorf(UnsupportedCurrencyError, "%s is not supported as target currency by %s", targetCurrency, BinancePriceProviderName)
+		return
+	}
+
+	year, month, day := at.UTC().Date()
+	startOfDay := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	url := fmt.Sprintf("https://api.binance.com/api/v3/klines?symbol=%s%s&interval=1d&startTime=%d&limit=1", id, quoteAsset, startOfDay.UnixNano()/int64(time.Millisecond))
+
+	// Each kline is an array of [open time, open, high, low, close, ...]
+	var response [][]interface{}
+	if err = provider.apiFetcher.Fetch(url, &response); err != nil {
+		return
+	}
+
+	if len(response) == 0 || len(response[0]) < 5 {
+		err = errorf(UnsupportedCurrencyError, "no %s%s price available on %s", id, quoteAsset, startOfDay.Format("2006-01-02"))
+		return
+	}
+
+	closePrice, ok := response[0][4].(string)
+	if !ok {
+		err = errorf(MalformedResponseError, "malformed %s%s kline", id, quoteAsset)
+		return
+	}
+
+	if price, err = strconv.ParseFloat(closePrice, 64); err != nil {
+		err = newError(MalformedResponseError, url, err)
+	}
+
+	return
+}
@@ -0,0 +1,72 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// BitcoindProvider is the name under which BitcoindInfoFetcher is registered
+const BitcoindProvider = "bitcoind"
+
+func init() {
+	RegisterProvider(BitcoindProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		var options JSONRPCOptions
+		if err := decodeProviderOptions(BitcoindProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		return NewBitcoindInfoFetcher(options, client)
+	})
+}
+
+// BitcoindInfoFetcher fetches balances from a Bitcoin Core-style full node (bitcoind, litecoind, dashd, ...) over JSON-RPC,
+// so that addresses are not disclosed to third parties. Nodes don't know about exchange rates, which must come from a price provider
+type BitcoindInfoFetcher struct {
+	rpcClient *jsonRPCClient
+}
+
+// NewBitcoindInfoFetcher creates an instance of BitcoindInfoFetcher for the node described by `options` from an HTTP client instance
+func NewBitcoindInfoFetcher(options JSONRPCOptions, client HTTPClient) (*BitcoindInfoFetcher, error) {
+	rpcClient, err := newJSONRPCClient(BitcoindProvider, client, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BitcoindInfoFetcher{rpcClient}, nil
+}
+
+// FetchBalance retrieves the aggregate balances of the provided addresses by scanning the node's UTXO set with `scantxoutset`.
+// Only confirmed outputs are taken into account, and the scan may take a few minutes on large chains
+func (fetcher *BitcoindInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	descriptors := make([]string, len(addresses))
+	for index, address := range addresses {
+		descriptors[index] = fmt.Sprintf("addr(%s)", address)
+	}
+
+	var result struct {
+		Success     bool    `json:"success"`
+		TotalAmount float64 `json:"total_amount"`
+	}
+	if *err = fetcher.rpcClient.call("scantxoutset", []interface{}{"start", descriptors}, &result); *err != nil {
+		return
+	}
+	if !result.Success {
+		*err = errorf(UnavailableError, "%s UTXO set scan was aborted", BitcoindProvider)
+		return
+	}
+
+	*balance = result.TotalAmount
+}
+
+// FetchExchangeRate always fails, since nodes don't provide exchange rates
+func (fetcher *BitcoindInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate = 0.
+	*err = errorf(UnsupportedCurrencyError, "%s does not provide exchange rates, please configure a price_provider", BitcoindProvider)
+
+	done.Done()
+}
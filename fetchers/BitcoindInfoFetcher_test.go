@@ -0,0 +1,127 @@
+package fetchers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+var errScanInProgress = errors.New(`Scan already in progress, use action "abort" or "status"`)
+
+// newFakeJSONRPCServer starts a local JSON-RPC server which checks the basic authentication credentials
+// and answers each request with the result returned by `handle`, or with a JSON-RPC error if it returns an error
+func newFakeJSONRPCServer(t *testing.T, user, password string, handle func(method string, params []json.RawMessage) (interface{}, error)) *httptest.Server {
+	type request struct {
+		ID     int               `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	respond := func(req *request) map[string]interface{} {
+		result, err := handle(req.Method, req.Params)
+		if err != nil {
+			return map[string]interface{}{"id": req.ID, "result": nil, "error": map[string]interface{}{"code": -8, "message": err.Error()}}
+		}
+		return map[string]interface{}{"id": req.ID, "result": result, "error": nil}
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requestUser, requestPassword, ok := r.BasicAuth(); user != "" && (!ok || requestUser != user || requestPassword != password) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.Equal(t, http.MethodPost, r.Method)
+
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if body[0] == '[' {
+			var requests []*request
+			require.NoError(t, json.Unmarshal(body, &requests))
+
+			// Answer in reverse order, as servers are allowed to
+			responses := make([]map[string]interface{}, len(requests))
+			for index, req := range requests {
+				responses[len(requests)-1-index] = respond(req)
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(responses))
+			return
+		}
+
+		var req request
+		require.NoError(t, json.Unmarshal(body, &req))
+		response := respond(&req)
+		if response["error"] != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(response))
+	}))
+}
+
+func TestBitcoindInfoFetcherFetchBalance(t *testing.T) {
+	server := newFakeJSONRPCServer(t, "rpcuser", "rpcpassword", func(method string, params []json.RawMessage) (interface{}, error) {
+		require.Equal(t, "scantxoutset", method)
+		require.JSONEq(t, `"start"`, string(params[0]))
+
+		var descriptors []string
+		require.NoError(t, json.Unmarshal(params[1], &descriptors))
+		if descriptors[0] == "addr(busy)" {
+			return nil, errScanInProgress
+		}
+		require.Equal(t, []string{"addr(a)", "addr(b)"}, descriptors)
+
+		return map[string]interface{}{"success": true, "txouts": 1000, "height": 700000, "unspents": []interface{}{}, "total_amount": 1.25}, nil
+	})
+	defer server.Close()
+
+	cookieFile := filepath.Join(t.TempDir(), ".cookie")
+	require.NoError(t, ioutil.WriteFile(cookieFile, []byte("rpcuser:rpcpassword\n"), os.ModePerm))
+
+	cases := []struct {
+		name                 string
+		options              fetchers.JSONRPCOptions
+		addresses            []string
+		expectedBalance      float64
+		expectedErrorMessage string
+	}{
+		{"user and password", fetchers.JSONRPCOptions{URL: server.URL, User: "rpcuser", Password: "rpcpassword"}, []string{"a", "b"}, 1.25, ""},
+		{"cookie file", fetchers.JSONRPCOptions{URL: server.URL, CookieFile: cookieFile}, []string{"a", "b"}, 1.25, ""},
+		{"wrong password", fetchers.JSONRPCOptions{URL: server.URL, User: "rpcuser", Password: "wrong"}, []string{"a", "b"}, 0., "401 Unauthorized"},
+		{"RPC error", fetchers.JSONRPCOptions{URL: server.URL, User: "rpcuser", Password: "rpcpassword"}, []string{"busy"}, 0., "json-rpc error -8: Scan already in progress, use action \"abort\" or \"status\""},
+	}
+
+	for _, testCase := range cases {
+		fetcher, err := fetchers.NewBitcoindInfoFetcher(testCase.options, server.Client())
+		require.NoError(t, err, testCase.name)
+
+		var balance float64
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalance(testCase.addresses, "", &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+			require.Equal(t, testCase.expectedBalance, balance, testCase.name)
+		}
+	}
+}
+
+func TestNewBitcoindInfoFetcherRequiresDoer(t *testing.T) {
+	_, err := fetchers.NewInfoFetcher(fetchers.BitcoindProvider, "BTC", new(mockHTTPClient), json.RawMessage(`{"url": "http://127.0.0.1:8332"}`))
+	require.EqualError(t, err, "bitcoind requires an HTTP client able to send POST requests, not *fetchers_test.mockHTTPClient")
+
+	_, err = fetchers.NewInfoFetcher(fetchers.BitcoindProvider, "BTC", http.DefaultClient, nil)
+	require.EqualError(t, err, "bitcoind requires a url option")
+}
@@ -0,0 +1,130 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/PombeirP/wallet-balance/address"
+)
+
+// BlockbookProvider is the name under which BlockbookInfoFetcher is registered
+const BlockbookProvider = "blockbook"
+
+// blockbookCoins maps ticker symbols to the public Trezor Blockbook instance and the number of decimals of the coin
+var blockbookCoins = map[string]struct {
+	url      string
+	decimals int
+}{
+	"BTC":  {"https://btc1.trezor.io", 8},
+	"BCH":  {"https://bch1.trezor.io", 8},
+	"LTC":  {"https://ltc1.trezor.io", 8},
+	"DASH": {"https://dash1.trezor.io", 8},
+	"DOGE": {"https://doge1.trezor.io", 8},
+	"ETH":  {"https://eth1.trezor.io", 18},
+}
+
+// blockbookOptions holds the options accepted by the blockbook provider
+type blockbookOptions struct {
+	// URL is the root URL of the Blockbook instance. Defaults to the public Trezor instance of the coin
+	URL string `json:"url,omitempty"`
+	// Decimals is the number of decimals of the coin. Defaults to 18 for ETH and 8 otherwise
+	Decimals int `json:"decimals,omitempty"`
+}
+
+func init() {
+	RegisterProvider(BlockbookProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		coin, ok := blockbookCoins[strings.ToUpper(symbol)]
+		options := blockbookOptions{URL: coin.url, Decimals: coin.decimals}
+		if err := decodeProviderOptions(BlockbookProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+		if options.URL == "" {
+			return nil, fmt.Errorf("%s requires a url option for %s", BlockbookProvider, symbol)
+		}
+		if !ok && options.Decimals == 0 {
+			options.Decimals = 8
+		}
+
+		return NewBlockbookInfoFetcher(options.URL, options.Decimals, client), nil
+	})
+}
+
+// BlockbookInfoFetcher fetches the balance and exchange rate of a coin from a Blockbook indexer (https://github.com/trezor/blockbook),
+// accepting both addresses and extended public keys or output descriptors
+type BlockbookInfoFetcher struct {
+	baseURL     string
+	unit        float64
+	jsonFetcher JSONFetcher
+}
+
+// NewBlockbookInfoFetcher creates an instance of BlockbookInfoFetcher for the instance at `baseURL`, for a coin with `decimals` decimals, from an HTTP client instance
+func NewBlockbookInfoFetcher(baseURL string, decimals int, client HTTPClient) *BlockbookInfoFetcher {
+	unit, _ := strconv.ParseFloat(fmt.Sprintf("1e%d", decimals), 64)
+	return &BlockbookInfoFetcher{strings.TrimSuffix(baseURL, "/"), unit, NewWebJSONFetcher(client)}
+}
+
+// FetchBalance retrieves the aggregate balances of the provided addresses or extended public keys, including unconfirmed funds
+func (fetcher *BlockbookInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	type blockbookBalanceResponse struct {
+		Balance            string `json:"balance"`
+		UnconfirmedBalance string `json:"unconfirmedBalance"`
+	}
+
+	// Each address or extended public key is queried separately
+	*balance, *err = fetchInBatches(addresses, 1, func(batch []string) (batchBalance float64, err error) {
+		endpoint := "address"
+		if address.IsExtendedPublicKey(batch[0]) {
+			endpoint = "xpub"
+		}
+
+		response := &blockbookBalanceResponse{}
+		apiURL := fmt.Sprintf("%s/api/v2/%s/%s?details=basic", fetcher.baseURL, endpoint, url.PathEscape(batch[0]))
+		if err = fetcher.jsonFetcher.Fetch(apiURL, response); err != nil {
+			return
+		}
+
+		for _, amount := range []string{response.Balance, response.UnconfirmedBalance} {
+			if amount == "" {
+				continue
+			}
+
+			partialBalance, parseErr := strconv.ParseFloat(amount, 64)
+			if parseErr != nil {
+				return 0, newError(MalformedResponseError, apiURL, parseErr)
+			}
+			batchBalance += partialBalance / fetcher.unit
+		}
+
+		return
+	})
+}
+
+// FetchExchangeRate retrieves the exchange rate of the coin in `targetCurrency` from the Blockbook instance's fiat rates
+func (fetcher *BlockbookInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate = 0.
+
+	var response struct {
+		Rates map[string]float64 `json:"rates"`
+	}
+	currency := strings.ToLower(targetCurrency)
+	if *err = fetcher.jsonFetcher.Fetch(fmt.Sprintf("%s/api/v2/tickers?currency=%s", fetcher.baseURL, currency), &response); *err != nil {
+		return
+	}
+
+	rate, ok := response.Rates[currency]
+	if !ok || rate <= 0 {
+		*err = errorf(UnsupportedCurrencyError, "%s has no %s exchange rate", BlockbookProvider, targetCurrency)
+		return
+	}
+	*exchangeRate = rate
+}
@@ -0,0 +1,80 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockbookInfoFetcherFetchBalance(t *testing.T) {
+	cases := []struct {
+		symbol          string
+		options         string
+		addresses       []string
+		responses       map[string]string
+		expectedBalance float64
+	}{
+		{"BTC", "", []string{"bc1qa", "xpub6CUGRU"},
+			map[string]string{
+				"https://btc1.trezor.io/api/v2/address/bc1qa?details=basic":   `{"address":"bc1qa","balance":"150000000","unconfirmedBalance":"-50000000"}`,
+				"https://btc1.trezor.io/api/v2/xpub/xpub6CUGRU?details=basic": `{"address":"xpub6CUGRU","balance":"200000000","unconfirmedBalance":"0","usedTokens":3}`,
+			}, 3.},
+		{"LTC", `{"url": "https://blockbook.local/"}`, []string{"wpkh(Ltub2Y)"},
+			map[string]string{
+				"https://blockbook.local/api/v2/xpub/wpkh%28Ltub2Y%29?details=basic": `{"address":"wpkh(Ltub2Y)","balance":"1000000","unconfirmedBalance":"0"}`,
+			}, 0.01},
+		{"ETH", "", []string{"0xa"},
+			map[string]string{
+				"https://eth1.trezor.io/api/v2/address/0xa?details=basic": `{"address":"0xa","balance":"1500000000000000000","unconfirmedBalance":"0"}`,
+			}, 1.5},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		for url, body := range testCase.responses {
+			clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+		}
+
+		infoFetcher, err := fetchers.NewInfoFetcher(fetchers.BlockbookProvider, testCase.symbol, clientMock, json.RawMessage(testCase.options))
+		require.NoError(t, err, testCase.symbol)
+
+		var balance float64
+		var wg sync.WaitGroup
+		wg.Add(1)
+		infoFetcher.FetchBalance(testCase.addresses, "", &balance, &err, &wg)
+		wg.Wait()
+
+		require.NoError(t, err, testCase.symbol)
+		require.InDelta(t, testCase.expectedBalance, balance, 1e-9, testCase.symbol)
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestBlockbookInfoFetcherFetchExchangeRate(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "https://doge1.trezor.io/api/v2/tickers?currency=usd").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"ts":1600000000,"rates":{"usd":0.0625}}`))}, nil).Once()
+	clientMock.On("Get", "https://doge1.trezor.io/api/v2/tickers?currency=xyz").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"ts":1600000000,"rates":{"xyz":-1}}`))}, nil).Once()
+
+	fetcher := fetchers.NewBlockbookInfoFetcher("https://doge1.trezor.io", 8, clientMock)
+
+	var exchangeRate float64
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	fetcher.FetchExchangeRate("", "usd", &exchangeRate, &err, &wg)
+	require.NoError(t, err)
+	require.Equal(t, 0.0625, exchangeRate)
+
+	fetcher.FetchExchangeRate("", "xyz", &exchangeRate, &err, &wg)
+	require.EqualError(t, err, "blockbook has no xyz exchange rate")
+	wg.Wait()
+
+	clientMock.AssertExpectations(t)
+}
@@ -1,40 +1,82 @@
 package fetchers
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
 	satoshi = 100000000. // 10^8
+
+	// blockchainInfoPageSize is the maximum number of transactions returned per page by the multiaddr API
+	blockchainInfoPageSize = 100
+
+	// blockchainInfoBatchSize is the maximum number of addresses queried in a single balance request
+	blockchainInfoBatchSize = 50
+
+	// BlockchainInfoProvider is the name under which BlockchainInfoFetcher is registered
+	BlockchainInfoProvider = "blockchain.info"
+
+	// blockchainInfoBaseURL is the root URL of the blockchain.info API
+	blockchainInfoBaseURL = "https://blockchain.info"
 )
 
+// blockchainInfoOptions holds the options accepted by the blockchain.info provider
+type blockchainInfoOptions struct {
+	// BaseURL overrides the root URL of the API, e.g. to point to a mirror or a test server
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+func init() {
+	RegisterProvider(BlockchainInfoProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		if !strings.EqualFold(symbol, "btc") {
+			return nil, fmt.Errorf("%s only supports BTC, not %s", BlockchainInfoProvider, symbol)
+		}
+
+		var options blockchainInfoOptions
+		if err := decodeProviderOptions(BlockchainInfoProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		fetcher := NewBlockchainInfoFetcher(client)
+		if options.BaseURL != "" {
+			fetcher.baseURL = strings.TrimSuffix(options.BaseURL, "/")
+		}
+
+		return fetcher, nil
+	})
+}
+
 // BlockchainInfoFetcher fetches the balance and exchange rate of BTC on https://blockchain.info/
 type BlockchainInfoFetcher struct {
-	apiFetcher NumberFetcher
+	baseURL     string
+	apiFetcher  NumberFetcher
+	jsonFetcher JSONFetcher
 }
 
 // NewBlockchainInfoFetcher creates an instance of BlockchainInfoFetcher from an HTTP client instance
 func NewBlockchainInfoFetcher(client HTTPClient) *BlockchainInfoFetcher {
 	numberFetcher := NewWebNumberFetcher(client)
-	return &BlockchainInfoFetcher{numberFetcher}
+	return &BlockchainInfoFetcher{blockchainInfoBaseURL, numberFetcher, NewWebJSONFetcher(client)}
 }
 
 // FetchBalance retrieves the aggregate balances on https://blockchain.info/ for the provided addresses
 func (fetcher *BlockchainInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
-	url := fmt.Sprintf("https://blockchain.info/q/addressbalance/%s", strings.Join(addresses, "%7C" /*|*/))
-
-	if *balance, *err = fetcher.apiFetcher.Fetch(url); *err == nil {
-		*balance = *balance / satoshi
-	}
+	*balance, *err = fetchInBatches(addresses, blockchainInfoBatchSize, func(batch []string) (float64, error) {
+		url := fmt.Sprintf("%s/q/addressbalance/%s", fetcher.baseURL, strings.Join(batch, "%7C" /*|*/))
+		return fetcher.apiFetcher.Fetch(url)
+	})
+	*balance = *balance / satoshi
 
 	done.Done()
 }
 
 // FetchExchangeRate retrieves the exchange rate for BTC in `targetCurrency`
 func (fetcher *BlockchainInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
-	url := fmt.Sprintf("https://blockchain.info/tobtc?currency=%s&value=1", targetCurrency)
+	url := fmt.Sprintf("%s/tobtc?currency=%s&value=1", fetcher.baseURL, targetCurrency)
 
 	if *exchangeRate, *err = fetcher.apiFetcher.Fetch(url); *err == nil {
 		*exchangeRate = 1. / *exchangeRate
@@ -42,3 +84,166 @@ func (fetcher *BlockchainInfoFetcher) FetchExchangeRate(apiKey string, targetCur
 
 	done.Done()
 }
+
+// blockchainInfoOutput holds the fields of a transaction output returned by the multiaddr API
+type blockchainInfoOutput struct {
+	Addr  string `json:"addr"`
+	Value int64  `json:"value"`
+}
+
+// blockchainInfoTransaction holds the fields of a transaction returned by the multiaddr API
+type blockchainInfoTransaction struct {
+	Hash        string `json:"hash"`
+	Time        int64  `json:"time"`
+	Result      int64  `json:"result"`
+	Fee         int64  `json:"fee"`
+	BlockHeight int    `json:"block_height"`
+	Inputs      []struct {
+		PrevOut *blockchainInfoOutput `json:"prev_out"`
+	} `json:"inputs"`
+	Out []*blockchainInfoOutput `json:"out"`
+}
+
+// blockchainInfoMultiAddrResponse holds the fields of a multiaddr API response
+type blockchainInfoMultiAddrResponse struct {
+	Wallet struct {
+		FinalBalance int64 `json:"final_balance"`
+	} `json:"wallet"`
+	Info struct {
+		LatestBlock struct {
+			Height int `json:"height"`
+		} `json:"latest_block"`
+	} `json:"info"`
+	Txs []*blockchainInfoTransaction `json:"txs"`
+}
+
+// fetchMultiAddrPage retrieves a page of the transactions of the provided addresses, most recent first
+func (fetcher *BlockchainInfoFetcher) fetchMultiAddrPage(addresses []string, offset int) (*blockchainInfoMultiAddrResponse, error) {
+	url := fmt.Sprintf("%s/multiaddr?active=%s&n=%d&offset=%d", fetcher.baseURL, strings.Join(addresses, "%7C" /*|*/), blockchainInfoPageSize, offset)
+	response := &blockchainInfoMultiAddrResponse{}
+
+	return response, fetcher.jsonFetcher.Fetch(url, response)
+}
+
+// FetchBalanceAt retrieves the aggregate balances of the provided addresses at `at`, by reverting the transactions that happened since
+func (fetcher *BlockchainInfoFetcher) FetchBalanceAt(addresses []string, apiKey string, at time.Time, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance, *err = fetchInBatches(addresses, blockchainInfoBatchSize, func(batch []string) (float64, error) {
+		return fetcher.fetchBatchBalanceAt(batch, at)
+	})
+}
+
+// fetchBatchBalanceAt retrieves the aggregate balances of a batch of addresses at `at`
+func (fetcher *BlockchainInfoFetcher) fetchBatchBalanceAt(addresses []string, at time.Time) (float64, error) {
+	var finalBalance, changeSince int64
+	seen := make(map[string]bool)
+	for offset := 0; ; offset += blockchainInfoPageSize {
+		response, err := fetcher.fetchMultiAddrPage(addresses, offset)
+		if err != nil {
+			return 0, err
+		}
+		if offset == 0 {
+			finalBalance = response.Wallet.FinalBalance
+		}
+
+		reachedAsOf := false
+		for _, tx := range response.Txs {
+			if tx.Time <= at.Unix() {
+				reachedAsOf = true
+				break
+			}
+			// Transactions received while paging shift the pages, so the last transactions of a page may be returned again on the next one
+			if seen[tx.Hash] {
+				continue
+			}
+			seen[tx.Hash] = true
+			changeSince += tx.Result
+		}
+
+		if reachedAsOf || len(response.Txs) < blockchainInfoPageSize {
+			return float64(finalBalance-changeSince) / satoshi, nil
+		}
+	}
+}
+
+// FetchTransactions retrieves the most recent transactions of the provided addresses on https://blockchain.info/
+func (fetcher *BlockchainInfoFetcher) FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, false)
+}
+
+// FetchTransactionHistory retrieves all the transactions of the provided addresses on https://blockchain.info/, one page at a time
+func (fetcher *BlockchainInfoFetcher) FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, true)
+}
+
+// fetchTransactions retrieves the first page of transactions of the provided addresses, or all pages if `allPages` is set, most recent first.
+// Addresses are queried in batches, which keeps the URLs short enough
+func (fetcher *BlockchainInfoFetcher) fetchTransactions(addresses []string, allPages bool) ([]*Transaction, error) {
+	// A transaction involving addresses of several batches is returned for each of them, with the movements of the addresses of the batch
+	set := newTransactionSet()
+	for _, batch := range splitAddresses(addresses, blockchainInfoBatchSize) {
+		if err := fetcher.fetchBatchTransactions(set, batch, allPages); err != nil {
+			return nil, err
+		}
+	}
+
+	return set.sorted(), nil
+}
+
+// fetchBatchTransactions adds the transactions of a batch of addresses to `set`, from the first page only unless `allPages` is set
+func (fetcher *BlockchainInfoFetcher) fetchBatchTransactions(set *transactionSet, addresses []string, allPages bool) error {
+	queried := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		queried[address] = true
+	}
+
+	seen := make(map[string]bool)
+	latestHeight := 0
+	for offset := 0; ; offset += blockchainInfoPageSize {
+		response, err := fetcher.fetchMultiAddrPage(addresses, offset)
+		if err != nil {
+			return err
+		}
+		if offset == 0 {
+			latestHeight = response.Info.LatestBlock.Height
+		}
+
+		for _, rawTx := range response.Txs {
+			// Transactions received while paging shift the pages, so the last transactions of a page may be returned again on the next one
+			if seen[rawTx.Hash] {
+				continue
+			}
+			seen[rawTx.Hash] = true
+
+			tx := set.get(rawTx.Hash)
+			tx.Time, tx.Fee = time.Unix(rawTx.Time, 0), float64(rawTx.Fee)/satoshi
+			if rawTx.BlockHeight > 0 {
+				tx.Confirmations = latestHeight - rawTx.BlockHeight + 1
+			}
+			for _, input := range rawTx.Inputs {
+				if input.PrevOut != nil && queried[input.PrevOut.Addr] {
+					tx.movement(input.PrevOut.Addr).Out += float64(input.PrevOut.Value) / satoshi
+				}
+			}
+			for _, output := range rawTx.Out {
+				if queried[output.Addr] {
+					tx.movement(output.Addr).In += float64(output.Value) / satoshi
+				}
+			}
+		}
+
+		if !allPages || len(response.Txs) < blockchainInfoPageSize {
+			return nil
+		}
+	}
+}
+
+// FetchDetailedBalance retrieves the aggregate confirmed and pending balances of the provided addresses on https://blockchain.info/
+func (fetcher *BlockchainInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	fetchDetailedBalanceFromTransactions(fetcher, addresses, apiKey, minConfirmations, balance, err, done)
+}
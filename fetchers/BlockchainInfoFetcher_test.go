@@ -0,0 +1,168 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockchainInfoFetcherFetchBalanceAt(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+
+	// 100 transactions after `at` on the first page, each receiving 1000 satoshis
+	firstPageTxs := make([]string, 100)
+	for index := range firstPageTxs {
+		firstPageTxs[index] = fmt.Sprintf(`{"hash":"tx%d","time":%d,"result":1000}`, index, at.Unix()+int64(200-index))
+	}
+
+	cases := []struct {
+		name                 string
+		pages                []string
+		expectedErrorMessage string
+		expectedBalance      float64
+	}{
+		{"no transactions since",
+			[]string{`{"wallet":{"final_balance":50000000},"txs":[{"hash":"a","time":1400000000,"result":50000000}]}`},
+			"", 0.5,
+		},
+		{"transactions since are reverted",
+			[]string{`{"wallet":{"final_balance":50000000},"txs":[{"hash":"b","time":1600000000,"result":-10000000},{"hash":"c","time":1550000000,"result":20000000},{"hash":"a","time":1400000000,"result":40000000}]}`},
+			"", 0.4,
+		},
+		{"transactions span several pages",
+			[]string{
+				`{"wallet":{"final_balance":300000},"txs":[` + strings.Join(firstPageTxs, ",") + `]}`,
+				`{"wallet":{"final_balance":300000},"txs":[{"hash":"d","time":1500000001,"result":100000},{"hash":"a","time":1400000000,"result":100000}]}`,
+			},
+			"", 0.001,
+		},
+		{"transactions repeated across pages are counted once",
+			[]string{
+				`{"wallet":{"final_balance":300000},"txs":[` + strings.Join(firstPageTxs, ",") + `]}`,
+				`{"wallet":{"final_balance":300000},"txs":[` + firstPageTxs[99] + `,{"hash":"d","time":1500000001,"result":100000},{"hash":"a","time":1400000000,"result":100000}]}`,
+			},
+			"", 0.001,
+		},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		for index, page := range testCase.pages {
+			url := fmt.Sprintf("https://blockchain.info/multiaddr?active=a%%7Cb&n=100&offset=%d", index*100)
+			clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(page))}, nil).Once()
+		}
+
+		fetcher := fetchers.NewBlockchainInfoFetcher(clientMock)
+
+		var balance float64
+		var err error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalanceAt([]string{"a", "b"}, "", at, &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+			require.InDelta(t, testCase.expectedBalance, balance, 1e-9, testCase.name)
+		}
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestBlockchainInfoFetcherFetchTransactionHistory(t *testing.T) {
+	// A full first page of transactions each receiving 1000 satoshis, followed by a page repeating its last transaction (shifted by a new one) and the oldest one
+	firstPageTxs := make([]string, 100)
+	for index := range firstPageTxs {
+		firstPageTxs[index] = fmt.Sprintf(`{"hash":"tx%d","time":%d,"block_height":%d,"out":[{"addr":"a","value":1000}]}`, index, 1500000200-index, 900-index)
+	}
+	pages := []string{
+		`{"info":{"latest_block":{"height":1000}},"txs":[` + strings.Join(firstPageTxs, ",") + `]}`,
+		`{"info":{"latest_block":{"height":1001}},"txs":[` + firstPageTxs[99] + `,{"hash":"first","time":1400000000,"block_height":100,"out":[{"addr":"b","value":100000000}]}]}`,
+	}
+
+	clientMock := new(mockHTTPClient)
+	for index, page := range pages {
+		url := fmt.Sprintf("https://blockchain.info/multiaddr?active=a%%7Cb&n=100&offset=%d", index*100)
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(page))}, nil).Once()
+	}
+
+	fetcher := fetchers.NewBlockchainInfoFetcher(clientMock)
+
+	var transactions []*fetchers.Transaction
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchTransactionHistory([]string{"a", "b"}, "", &transactions, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 101)
+	total := 0.
+	for _, tx := range transactions {
+		total += tx.NetAmount()
+	}
+	require.InDelta(t, 1.001, total, 1e-9)
+	require.Equal(t, "first", transactions[100].TxID)
+	require.Equal(t, 901, transactions[100].Confirmations)
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestBlockchainInfoFetcherBatchesMultiAddr(t *testing.T) {
+	addresses := make([]string, 60)
+	for index := range addresses {
+		addresses[index] = fmt.Sprintf("a%d", index)
+	}
+	// A transfer from the first batch to the second, returned in full for each batch
+	transfer := `{"hash":"transfer","time":1500000100,"result":%d,"fee":1000,"block_height":990,"inputs":[{"prev_out":{"addr":"a0","value":5000}}],"out":[{"addr":"a55","value":4000}]}`
+	responses := map[string]string{
+		"https://blockchain.info/multiaddr?active=" + strings.Join(addresses[:50], "%7C") + "&n=100&offset=0": `{"wallet":{"final_balance":10000},"info":{"latest_block":{"height":1000}},"txs":[` +
+			fmt.Sprintf(transfer, -5000) + `,{"hash":"deposit","time":1400000000,"result":15000,"block_height":500,"out":[{"addr":"a1","value":15000}]}]}`,
+		"https://blockchain.info/multiaddr?active=" + strings.Join(addresses[50:], "%7C") + "&n=100&offset=0": `{"wallet":{"final_balance":4000},"info":{"latest_block":{"height":1000}},"txs":[` +
+			fmt.Sprintf(transfer, 4000) + `]}`,
+	}
+
+	clientMock := new(mockHTTPClient)
+	for url, body := range responses {
+		// Once for the history and once for the balance, each with a fresh body
+		for range [2]struct{}{} {
+			clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+		}
+	}
+
+	fetcher := fetchers.NewBlockchainInfoFetcher(clientMock)
+
+	var transactions []*fetchers.Transaction
+	var balance float64
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	fetcher.FetchTransactionHistory(addresses, "", &transactions, &err, &wg)
+	require.NoError(t, err)
+	fetcher.FetchBalanceAt(addresses, "", time.Unix(1450000000, 0), &balance, &err, &wg)
+	require.NoError(t, err)
+	wg.Wait()
+
+	// The movements of both batches are merged, so that the transfer only costs its fee
+	require.Len(t, transactions, 2)
+	require.Equal(t, "transfer", transactions[0].TxID)
+	require.Len(t, transactions[0].Movements, 2)
+	require.InDelta(t, -0.00001, transactions[0].NetAmount(), 1e-12)
+	require.Equal(t, 11, transactions[0].Confirmations)
+	require.InDelta(t, 0.00015, transactions[1].NetAmount(), 1e-12)
+
+	require.InDelta(t, 0.00015, balance, 1e-12)
+
+	clientMock.AssertExpectations(t)
+}
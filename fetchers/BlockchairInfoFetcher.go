@@ -0,0 +1,135 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/PombeirP/wallet-balance/address"
+)
+
+const (
+	// BlockchairProvider is the name under which BlockchairInfoFetcher is registered
+	BlockchairProvider = "blockchair"
+
+	// blockchairBitcoinCashChain is the Blockchair chain identifier of Bitcoin Cash, whose addresses are sent in CashAddr format
+	blockchairBitcoinCashChain = "bitcoin-cash"
+
+	// blockchairBaseURL is the default root URL of the Blockchair API
+	blockchairBaseURL = "https://api.blockchair.com"
+
+	// blockchairBatchSize is the maximum number of addresses queried in a single balance request
+	blockchairBatchSize = 100
+)
+
+// blockchairChains maps ticker symbols to the chain identifiers used in https://api.blockchair.com/ URLs
+var blockchairChains = map[string]string{
+	"BTC":  "bitcoin",
+	"BCH":  blockchairBitcoinCashChain,
+	"BCC":  blockchairBitcoinCashChain,
+	"LTC":  "litecoin",
+	"DASH": "dash",
+	"DOGE": "dogecoin",
+}
+
+// blockchairOptions holds the options accepted by the blockchair provider
+type blockchairOptions struct {
+	// Chain is the chain identifier used in https://api.blockchair.com/ URLs. Defaults to the chain of the ticker symbol
+	Chain string `json:"chain,omitempty"`
+	// BaseURL overrides the root URL of the API, e.g. to point to a test server
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+func init() {
+	RegisterProvider(BlockchairProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		options := blockchairOptions{Chain: blockchairChains[strings.ToUpper(symbol)]}
+		if err := decodeProviderOptions(BlockchairProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+		if options.Chain == "" {
+			return nil, fmt.Errorf("%s requires a chain option for %s", BlockchairProvider, symbol)
+		}
+
+		fetcher := NewBlockchairInfoFetcher(options.Chain, client)
+		if options.BaseURL != "" {
+			fetcher.baseURL = strings.TrimSuffix(options.BaseURL, "/")
+		}
+
+		return fetcher, nil
+	})
+}
+
+// BlockchairInfoFetcher fetches the balance and exchange rate of a crypto-currency on https://blockchair.com/
+type BlockchairInfoFetcher struct {
+	baseURL     string
+	chain       string
+	jsonFetcher JSONFetcher
+}
+
+// NewBlockchairInfoFetcher creates an instance of BlockchairInfoFetcher for a specified chain from an HTTP client instance
+func NewBlockchairInfoFetcher(chain string, client HTTPClient) *BlockchairInfoFetcher {
+	return &BlockchairInfoFetcher{baseURL: blockchairBaseURL, chain: chain, jsonFetcher: NewWebJSONFetcher(client)}
+}
+
+// FetchBalance retrieves the aggregate balances on https://blockchair.com/ for the provided addresses
+func (fetcher *BlockchairInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	queryAddresses := addresses
+	if fetcher.chain == blockchairBitcoinCashChain {
+		// Blockchair expects Bitcoin Cash addresses in CashAddr format, without the prefix
+		queryAddresses = make([]string, len(addresses))
+		for index, legacyOrCashAddr := range addresses {
+			cashAddr, convertErr := address.ToCashAddr(legacyOrCashAddr)
+			if convertErr != nil {
+				*err = errorf(InvalidAddressError, "invalid address %s: %s", legacyOrCashAddr, convertErr)
+				return
+			}
+			queryAddresses[index] = strings.TrimPrefix(cashAddr, address.CashAddrPrefix+":")
+		}
+	}
+
+	*balance, *err = fetchInBatches(queryAddresses, blockchairBatchSize, func(batch []string) (batchBalance float64, err error) {
+		url := fmt.Sprintf("%s/%s/addresses/balances?addresses=%s", fetcher.baseURL, fetcher.chain, strings.Join(batch, ","))
+		if apiKey != "" {
+			url += "&key=" + apiKey
+		}
+
+		var response struct {
+			Data map[string]int64 `json:"data"`
+		}
+		if err = fetcher.jsonFetcher.Fetch(url, &response); err != nil {
+			return
+		}
+
+		// Addresses without any activity are omitted from the response
+		for _, partialBalance := range response.Data {
+			batchBalance += float64(partialBalance) / satoshi
+		}
+
+		return
+	})
+}
+
+// FetchExchangeRate retrieves the exchange rate of the chain's currency in `targetCurrency`. Blockchair only reports USD prices
+func (fetcher *BlockchairInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	if !strings.EqualFold(targetCurrency, "usd") {
+		*err = errorf(UnsupportedCurrencyError, "%s does not support exchange rates in %s", BlockchairProvider, targetCurrency)
+		return
+	}
+
+	var response struct {
+		Data struct {
+			MarketPriceUsd float64 `json:"market_price_usd"`
+		} `json:"data"`
+	}
+	url := fmt.Sprintf("%s/%s/stats", fetcher.baseURL, fetcher.chain)
+	if *err = fetcher.jsonFetcher.Fetch(url, &response); *err == nil {
+		*exchangeRate = response.Data.MarketPriceUsd
+	}
+}
@@ -0,0 +1,94 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockchairInfoFetcherFetchBalance(t *testing.T) {
+	cases := []struct {
+		chain                string
+		addresses            []string
+		expectedURL          string
+		responseBody         string
+		expectedBalance      float64
+		expectedErrorMessage string
+	}{
+		{"bitcoin-cash", []string{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"},
+			"https://api.blockchair.com/bitcoin-cash/addresses/balances?addresses=qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a,qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy",
+			`{"data":{"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a":150000000,"qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy":50000000}}`, 2., ""},
+		{"dogecoin", []string{"a", "b"}, "https://api.blockchair.com/dogecoin/addresses/balances?addresses=a,b", `{"data":{"a":100000000}}`, 1., ""},
+		{"bitcoin-cash", []string{"bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuz"}, "", "", 0., "invalid address bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuz: invalid checksum"},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		if testCase.expectedURL != "" {
+			clientMock.On("Get", testCase.expectedURL).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(testCase.responseBody))}, nil).Once()
+		}
+
+		fetcher := fetchers.NewBlockchairInfoFetcher(testCase.chain, clientMock)
+
+		var balance float64
+		var err error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalance(testCase.addresses, "", &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage)
+		} else {
+			require.NoError(t, err)
+			require.InDelta(t, testCase.expectedBalance, balance, 1e-9)
+		}
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestBlockchairInfoFetcherFetchExchangeRate(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "https://api.blockchair.com/bitcoin-cash/stats").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"data":{"market_price_usd":2512.5}}`))}, nil).Once()
+
+	fetcher := fetchers.NewBlockchairInfoFetcher("bitcoin-cash", clientMock)
+
+	var exchangeRate float64
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	fetcher.FetchExchangeRate("", "USD", &exchangeRate, &err, &wg)
+	require.NoError(t, err)
+	require.Equal(t, 2512.5, exchangeRate)
+
+	fetcher.FetchExchangeRate("", "EUR", &exchangeRate, &err, &wg)
+	require.EqualError(t, err, "blockchair does not support exchange rates in EUR")
+	wg.Wait()
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestBlockchairInfoFetcherBaseURL(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "http://localhost:3000/litecoin/stats").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"data":{"market_price_usd":84.25}}`))}, nil).Once()
+
+	infoFetcher, err := fetchers.NewInfoFetcher(fetchers.BlockchairProvider, "LTC", clientMock, json.RawMessage(`{"base_url": "http://localhost:3000/"}`))
+	require.NoError(t, err)
+
+	var exchangeRate float64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	infoFetcher.FetchExchangeRate("", "USD", &exchangeRate, &err, &wg)
+	wg.Wait()
+	require.NoError(t, err)
+	require.Equal(t, 84.25, exchangeRate)
+
+	clientMock.AssertExpectations(t)
+}
@@ -0,0 +1,98 @@
+package fetchers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CoinGeckoPriceProviderName is the name under which CoinGeckoPriceProvider is registered
+const CoinGeckoPriceProviderName = "coingecko"
+
+// coinGeckoIDs maps ticker symbols to CoinGecko coin identifiers
+var coinGeckoIDs = map[string]string{
+	"BTC":   "bitcoin",
+	"BCH":   "bitcoin-cash",
+	"BCC":   "bitcoin-cash",
+	"BNB":   "binancecoin",
+	"DASH":  "dash",
+	"DOGE":  "dogecoin",
+	"ETH":   "ethereum",
+	"LBTC":  "bitcoin", // Liquid bitcoin is pegged 1:1 to BTC
+	"LTC":   "litecoin",
+	"MATIC": "matic-network",
+	"UNO":   "unobtanium",
+}
+
+func init() {
+	RegisterPriceProvider(CoinGeckoPriceProviderName, func(client HTTPClient) PriceProvider {
+		return NewCoinGeckoPriceProvider(client)
+	})
+}
+
+// CoinGeckoPriceProvider fetches crypto-currency prices from the https://www.coingecko.com/ simple price API
+type CoinGeckoPriceProvider struct {
+	apiFetcher JSONFetcher
+}
+
+// NewCoinGeckoPriceProvider creates an instance of CoinGeckoPriceProvider from an HTTP client instance
+func NewCoinGeckoPriceProvider(client HTTPClient) *CoinGeckoPriceProvider {
+	return &CoinGeckoPriceProvider{NewWebJSONFetcher(client)}
+}
+
+// PriceID returns the CoinGecko coin identifier for `symbol`, falling back to the lower-case symbol for unknown coins
+func (provider *CoinGeckoPriceProvider) PriceID(symbol string) string {
+	if id, ok := coinGeckoIDs[strings.ToUpper(symbol)]; ok {
+		return id
+	}
+
+	return strings.ToLower(symbol)
+}
+
+// FetchPrices retrieves the prices in `targetCurrency` of the coins identified by `ids` in a single call
+func (provider *CoinGeckoPriceProvider) FetchPrices(ids []string, targetCurrency string) (prices map[string]float64, err error) {
+	targetCurrency = strings.ToLower(targetCurrency)
+	url := fmt.Sprintf("https://api.coingecko.com/api/v3/simple/price?ids=%s&vs_currencies=%s", url.QueryEscape(strings.Join(ids, ",")), targetCurrency)
+
+	var response map[string]map[string]float64
+	if err = provider.apiFetcher.Fetch(url, &response); err != nil {
+		return
+	}
+
+	prices = make(map[string]float64, len(response))
+	for id, coinPrices := range response {
+		if price, ok := coinPrices[targetCurrency]; ok {
+			prices[id] = price
+		}
+	}
+
+	return
+}
+
+// FetchHistoricalPrice retrieves the daily price in `targetCurrency` of the coin identified by `id` on the date of `at`
+func (provider *CoinGeckoPriceProvider) FetchHistoricalPrice(id string, targetCurrency string, at time.Time) (price float64, err error) {
+	targetCurrency = strings.ToLower(targetCurrency)
+	url := fmt.Sprintf("https://api.coingecko.com/api/v3/coins/%s/history?date=%s&localization=false", url.PathEscape(id), at.UTC().Format("02-01-2006"))
+
+	var response struct {
+		MarketData *struct {
+			CurrentPrice map[string]float64 `json:"current_price"`
+		} `json:"market_data"`
+	}
+	if err = provider.apiFetcher.Fetch(url, &response); err != nil {
+		return
+	}
+
+	if response.MarketData == nil {
+		err = errorf(UnsupportedCurrencyError, "no %s price available for %s on %s", strings.ToUpper(targetCurrency), id, at.UTC().Format("2006-01-02"))
+		return
+	}
+
+	price, ok := response.MarketData.CurrentPrice[targetCurrency]
+	if !ok {
+		err = errorf(UnsupportedCurrencyError, "no %s price available for %s on %s", strings.ToUpper(targetCurrency), id, at.UTC().Format("2006-01-02"))
+	}
+
+	return
+}
@@ -0,0 +1,87 @@
+package fetchers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CompositeInfoFetcher implements CryptoCurrencyInfoFetcher by combining independent balance and exchange rate fetchers
+type CompositeInfoFetcher struct {
+	CryptoCurrencyBalanceFetcher
+	CryptoCurrencyExchangeRateFetcher
+}
+
+// NewCompositeInfoFetcher creates an instance of CompositeInfoFetcher from a balance fetcher and an exchange rate fetcher
+func NewCompositeInfoFetcher(balanceFetcher CryptoCurrencyBalanceFetcher, exchangeRateFetcher CryptoCurrencyExchangeRateFetcher) *CompositeInfoFetcher {
+	return &CompositeInfoFetcher{balanceFetcher, exchangeRateFetcher}
+}
+
+// ExchangeRateQuotes returns the quotes reported by the exchange rate fetcher, if it implements ExchangeRateAuditor
+func (fetcher *CompositeInfoFetcher) ExchangeRateQuotes() ([]*ExchangeRateQuote, float64) {
+	if auditor, ok := fetcher.CryptoCurrencyExchangeRateFetcher.(ExchangeRateAuditor); ok {
+		return auditor.ExchangeRateQuotes()
+	}
+
+	return nil, 0.
+}
+
+// FetchBalanceAt retrieves the historical balance from the balance fetcher, if it implements CryptoCurrencyHistoricalBalanceFetcher
+func (fetcher *CompositeInfoFetcher) FetchBalanceAt(addresses []string, apiKey string, at time.Time, balance *float64, err *error, done *sync.WaitGroup) {
+	historicalFetcher, ok := fetcher.CryptoCurrencyBalanceFetcher.(CryptoCurrencyHistoricalBalanceFetcher)
+	if !ok {
+		*balance, *err = 0., fmt.Errorf("historical balances are not supported by %T", fetcher.CryptoCurrencyBalanceFetcher)
+		done.Done()
+		return
+	}
+
+	historicalFetcher.FetchBalanceAt(addresses, apiKey, at, balance, err, done)
+}
+
+// FetchExchangeRateAt retrieves the historical exchange rate from the exchange rate fetcher, if it implements CryptoCurrencyHistoricalExchangeRateFetcher
+func (fetcher *CompositeInfoFetcher) FetchExchangeRateAt(apiKey string, targetCurrency string, at time.Time, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	historicalFetcher, ok := fetcher.CryptoCurrencyExchangeRateFetcher.(CryptoCurrencyHistoricalExchangeRateFetcher)
+	if !ok {
+		*exchangeRate, *err = 0., fmt.Errorf("historical exchange rates are not supported by %T", fetcher.CryptoCurrencyExchangeRateFetcher)
+		done.Done()
+		return
+	}
+
+	historicalFetcher.FetchExchangeRateAt(apiKey, targetCurrency, at, exchangeRate, err, done)
+}
+
+// FetchTransactions retrieves the transactions from the balance fetcher, if it implements CryptoCurrencyTransactionFetcher
+func (fetcher *CompositeInfoFetcher) FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	transactionFetcher, ok := fetcher.CryptoCurrencyBalanceFetcher.(CryptoCurrencyTransactionFetcher)
+	if !ok {
+		*transactions, *err = nil, fmt.Errorf("transaction history is not supported by %T", fetcher.CryptoCurrencyBalanceFetcher)
+		done.Done()
+		return
+	}
+
+	transactionFetcher.FetchTransactions(addresses, apiKey, transactions, err, done)
+}
+
+// FetchTransactionHistory retrieves the complete transaction history from the balance fetcher, if it implements CryptoCurrencyTransactionHistoryFetcher
+func (fetcher *CompositeInfoFetcher) FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	historyFetcher, ok := fetcher.CryptoCurrencyBalanceFetcher.(CryptoCurrencyTransactionHistoryFetcher)
+	if !ok {
+		*transactions, *err = nil, fmt.Errorf("complete transaction history is not supported by %T", fetcher.CryptoCurrencyBalanceFetcher)
+		done.Done()
+		return
+	}
+
+	historyFetcher.FetchTransactionHistory(addresses, apiKey, transactions, err, done)
+}
+
+// FetchDetailedBalance retrieves the confirmed and pending balances from the balance fetcher, if it implements CryptoCurrencyDetailedBalanceFetcher
+func (fetcher *CompositeInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	detailedFetcher, ok := fetcher.CryptoCurrencyBalanceFetcher.(CryptoCurrencyDetailedBalanceFetcher)
+	if !ok {
+		*balance, *err = DetailedBalance{}, fmt.Errorf("confirmed balances are not supported by %T", fetcher.CryptoCurrencyBalanceFetcher)
+		done.Done()
+		return
+	}
+
+	detailedFetcher.FetchDetailedBalance(addresses, apiKey, minConfirmations, balance, err, done)
+}
@@ -0,0 +1,38 @@
+package fetchers
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextHTTPClient sends the requests of an HTTPDoer with a context, so that they are cancelled once it is done
+type contextHTTPClient struct {
+	ctx    context.Context
+	client HTTPDoer
+}
+
+// NewContextHTTPClient returns an HTTPClient sending requests with `client` that are cancelled once `ctx` is done.
+// Clients which don't implement HTTPDoer can't attach a context to their requests, and are returned as is
+func NewContextHTTPClient(ctx context.Context, client HTTPClient) HTTPClient {
+	doer, ok := client.(HTTPDoer)
+	if !ok {
+		return client
+	}
+
+	return &contextHTTPClient{ctx, doer}
+}
+
+// Get performs a GET request on `url`
+func (client *contextHTTPClient) Get(url string) (resp *http.Response, err error) {
+	req, err := http.NewRequestWithContext(client.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.client.Do(req)
+}
+
+// Do sends `req` with the context of the client
+func (client *contextHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	return client.client.Do(req.WithContext(client.ctx))
+}
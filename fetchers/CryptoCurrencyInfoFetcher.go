@@ -1,6 +1,9 @@
 package fetchers
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 // CryptoCurrencyBalanceFetcher defines the interface for fetching a crypto-currency balance
 type CryptoCurrencyBalanceFetcher interface {
@@ -17,3 +20,43 @@ type CryptoCurrencyInfoFetcher interface {
 	CryptoCurrencyBalanceFetcher
 	CryptoCurrencyExchangeRateFetcher
 }
+
+// CryptoCurrencyHistoricalBalanceFetcher defines the interface for fetching a crypto-currency balance as of a past point in time
+type CryptoCurrencyHistoricalBalanceFetcher interface {
+	FetchBalanceAt(addresses []string, apiKey string, at time.Time, balance *float64, err *error, done *sync.WaitGroup)
+}
+
+// CryptoCurrencyHistoricalExchangeRateFetcher defines the interface for fetching the daily exchange rate of a crypto-currency on a past date
+type CryptoCurrencyHistoricalExchangeRateFetcher interface {
+	FetchExchangeRateAt(apiKey string, targetCurrency string, at time.Time, exchangeRate *float64, err *error, done *sync.WaitGroup)
+}
+
+// CryptoCurrencyHistoricalInfoFetcher defines the interface for fetching the balance and exchange rate of a crypto-currency as of a past point in time
+type CryptoCurrencyHistoricalInfoFetcher interface {
+	CryptoCurrencyHistoricalBalanceFetcher
+	CryptoCurrencyHistoricalExchangeRateFetcher
+}
+
+// CryptoCurrencyTransactionFetcher defines the interface for fetching the recent transactions of crypto-currency addresses, most recent first
+type CryptoCurrencyTransactionFetcher interface {
+	FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup)
+}
+
+// CryptoCurrencyTransactionHistoryFetcher defines the interface for fetching the complete transaction history of crypto-currency addresses, most recent first.
+// Unlike CryptoCurrencyTransactionFetcher, all pages of the history are fetched, so that balances can be reconstructed from it
+type CryptoCurrencyTransactionHistoryFetcher interface {
+	FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup)
+}
+
+// DetailedBalance holds the confirmed balance of crypto-currency addresses along with the amounts pending confirmation
+type DetailedBalance struct {
+	Confirmed       float64
+	PendingIncoming float64
+	PendingOutgoing float64
+}
+
+// CryptoCurrencyDetailedBalanceFetcher defines the interface for fetching the confirmed and pending balances of crypto-currency addresses,
+// where funds with less than `minConfirmations` confirmations are considered pending
+type CryptoCurrencyDetailedBalanceFetcher interface {
+	FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup)
+}
@@ -1,20 +1,83 @@
 package fetchers
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/address"
+)
+
+const (
+	// CryptoidProvider is the name under which CryptoidInfoFetcher is registered
+	CryptoidProvider = "cryptoid"
+
+	// cryptoidBaseURL is the root URL of the chainz.cryptoid.info API
+	cryptoidBaseURL = "https://chainz.cryptoid.info"
 )
 
+// cryptoidOptions holds the options accepted by the cryptoid provider
+type cryptoidOptions struct {
+	// Currency is the currency identifier used in chainz.cryptoid.info URLs. Defaults to the lower-case ticker symbol
+	Currency string `json:"currency,omitempty"`
+	// BaseURL overrides the root URL of the API, e.g. to point to a test server
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+func init() {
+	RegisterProvider(CryptoidProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		options := cryptoidOptions{Currency: strings.ToLower(symbol)}
+		if err := decodeProviderOptions(CryptoidProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		fetcher := NewCryptoidInfoFetcher(options.Currency, client)
+		if options.BaseURL != "" {
+			fetcher.baseURL = strings.TrimSuffix(options.BaseURL, "/")
+		}
+		if upperSymbol := strings.ToUpper(symbol); upperSymbol == "BCH" || upperSymbol == "BCC" {
+			// Cryptoid only understands legacy Bitcoin Cash addresses
+			fetcher.convertAddress = address.ToLegacy
+		}
+
+		return fetcher, nil
+	})
+}
+
 // CryptoidInfoFetcher fetches the balance and exchange rate of several altcoins on https://chainz.cryptoid.info/
 type CryptoidInfoFetcher struct {
-	currency   string
-	apiFetcher NumberFetcher
+	baseURL     string
+	currency    string
+	apiFetcher  NumberFetcher
+	jsonFetcher JSONFetcher
+
+	// convertAddress converts addresses to the format expected by cryptoid. Optional
+	convertAddress func(string) (string, error)
 }
 
 // NewCryptoidInfoFetcher creates an instance of CryptoidInfoFetcher for a specified altcoin from an HTTP client instance
 func NewCryptoidInfoFetcher(currency string, client HTTPClient) *CryptoidInfoFetcher {
 	numberFetcher := NewWebNumberFetcher(client)
-	return &CryptoidInfoFetcher{currency, numberFetcher}
+	return &CryptoidInfoFetcher{baseURL: cryptoidBaseURL, currency: currency, apiFetcher: numberFetcher, jsonFetcher: NewWebJSONFetcher(client)}
+}
+
+// convertAddresses converts the provided addresses to the format expected by cryptoid
+func (fetcher *CryptoidInfoFetcher) convertAddresses(addresses []string) ([]string, error) {
+	if fetcher.convertAddress == nil {
+		return addresses, nil
+	}
+
+	converted := make([]string, len(addresses))
+	for index, original := range addresses {
+		var err error
+		if converted[index], err = fetcher.convertAddress(original); err != nil {
+			return nil, errorf(InvalidAddressError, "invalid address %s: %s", original, err)
+		}
+	}
+
+	return converted, nil
 }
 
 // FetchBalance retrieves the aggregate balances on https://chainz.cryptoid.info/ for the provided addresses
@@ -22,36 +85,76 @@ func (fetcher *CryptoidInfoFetcher) FetchBalance(addresses []string, apiKey stri
 	*err = nil
 	*balance = 0.
 
-	balancesChan := make(chan float64)
-	errorsChan := make(chan error)
-
-	for _, address := range addresses {
-		url := fmt.Sprintf("https://chainz.cryptoid.info/%s/api.dws?q=getbalance&key=%s&a=%s", fetcher.currency, apiKey, address)
-		go func() {
-			balance, err := fetcher.apiFetcher.Fetch(url)
-			if err == nil {
-				balancesChan <- balance
-			} else {
-				errorsChan <- err
-			}
-		}()
+	if addresses, *err = fetcher.convertAddresses(addresses); *err != nil {
+		done.Done()
+		return
 	}
 
-	for range addresses {
-		select {
-		case *err = <-errorsChan:
-		case partialBalance := <-balancesChan:
-			*balance += partialBalance
-		}
-	}
+	// Cryptoid only accepts a single address per request
+	*balance, *err = fetchInBatches(addresses, 1, func(batch []string) (float64, error) {
+		url := fmt.Sprintf("%s/%s/api.dws?q=getbalance&key=%s&a=%s", fetcher.baseURL, fetcher.currency, apiKey, batch[0])
+		return fetcher.apiFetcher.Fetch(url)
+	})
 
 	done.Done()
 }
 
 // FetchExchangeRate retrieves the exchange rate for BTC in `targetCurrency`
 func (fetcher *CryptoidInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
-	url := fmt.Sprintf("https://chainz.cryptoid.info/%s/api.dws?q=ticker.%s&key=%s", fetcher.currency, targetCurrency, apiKey)
+	url := fmt.Sprintf("%s/%s/api.dws?q=ticker.%s&key=%s", fetcher.baseURL, fetcher.currency, targetCurrency, apiKey)
 	*exchangeRate, *err = fetcher.apiFetcher.Fetch(url)
 
 	done.Done()
 }
+
+// FetchTransactions retrieves the most recent transactions of the provided addresses on https://chainz.cryptoid.info/.
+// Cryptoid only reports the net change of each transaction, so each address is queried separately and fees are not available.
+func (fetcher *CryptoidInfoFetcher) FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions = nil
+
+	type cryptoidTransaction struct {
+		Hash          string  `json:"hash"`
+		Confirmations int     `json:"confirmations"`
+		Change        float64 `json:"change"`
+		TimeUTC       string  `json:"time_utc"`
+	}
+
+	type cryptoidMultiAddrResponse struct {
+		Txs []*cryptoidTransaction `json:"txs"`
+	}
+
+	if addresses, *err = fetcher.convertAddresses(addresses); *err != nil {
+		return
+	}
+
+	set := newTransactionSet()
+	for _, address := range addresses {
+		response := &cryptoidMultiAddrResponse{}
+		url := fmt.Sprintf("%s/%s/api.dws?q=multiaddr&active=%s&key=%s", fetcher.baseURL, fetcher.currency, address, apiKey)
+		if *err = fetcher.jsonFetcher.Fetch(url, response); *err != nil {
+			return
+		}
+
+		for _, rawTx := range response.Txs {
+			tx := set.get(rawTx.Hash)
+			if tx.Time, *err = time.Parse(time.RFC3339, rawTx.TimeUTC); *err != nil {
+				return
+			}
+			tx.Confirmations = rawTx.Confirmations
+			if rawTx.Change >= 0 {
+				tx.movement(address).In += rawTx.Change
+			} else {
+				tx.movement(address).Out -= rawTx.Change
+			}
+		}
+	}
+
+	*transactions = set.sorted()
+}
+
+// FetchDetailedBalance retrieves the aggregate confirmed and pending balances of the provided addresses on https://chainz.cryptoid.info/
+func (fetcher *CryptoidInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	fetchDetailedBalanceFromTransactions(fetcher, addresses, apiKey, minConfirmations, balance, err, done)
+}
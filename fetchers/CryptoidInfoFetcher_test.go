@@ -0,0 +1,77 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCryptoidInfoFetcherFetchDetailedBalance(t *testing.T) {
+	responses := map[string]string{
+		"https://chainz.cryptoid.info/dash/api.dws?q=getbalance&key=key&a=a": "10.5",
+		"https://chainz.cryptoid.info/dash/api.dws?q=getbalance&key=key&a=b": "2",
+		"https://chainz.cryptoid.info/dash/api.dws?q=multiaddr&active=a&key=key": `{"txs":[
+			{"hash":"tx3","confirmations":0,"change":1.5,"time_utc":"2017-12-03T00:00:00Z"},
+			{"hash":"tx2","confirmations":3,"change":-0.5,"time_utc":"2017-12-02T00:00:00Z"},
+			{"hash":"tx1","confirmations":100,"change":9.5,"time_utc":"2017-12-01T00:00:00Z"}]}`,
+		"https://chainz.cryptoid.info/dash/api.dws?q=multiaddr&active=b&key=key": `{"txs":[
+			{"hash":"tx4","confirmations":200,"change":2,"time_utc":"2017-11-01T00:00:00Z"}]}`,
+	}
+
+	cases := []struct {
+		minConfirmations int
+		expectedBalance  fetchers.DetailedBalance
+	}{
+		{1, fetchers.DetailedBalance{Confirmed: 11., PendingIncoming: 1.5}},
+		{6, fetchers.DetailedBalance{Confirmed: 11.5, PendingIncoming: 1.5, PendingOutgoing: 0.5}},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		for url, body := range responses {
+			clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+		}
+
+		fetcher := fetchers.NewCryptoidInfoFetcher("dash", clientMock)
+
+		var balance fetchers.DetailedBalance
+		var err error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchDetailedBalance([]string{"a", "b"}, "key", testCase.minConfirmations, &balance, &err, &wg)
+		wg.Wait()
+
+		require.NoError(t, err)
+		require.InDelta(t, testCase.expectedBalance.Confirmed, balance.Confirmed, 1e-9, "confirmed balance with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingIncoming, balance.PendingIncoming, 1e-9, "pending incoming with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingOutgoing, balance.PendingOutgoing, 1e-9, "pending outgoing with %d confirmations", testCase.minConfirmations)
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestCryptoidInfoFetcherConvertsBitcoinCashAddressesToLegacy(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "https://chainz.cryptoid.info/bch/api.dws?q=getbalance&key=&a=1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString("1.5"))}, nil).Once()
+
+	infoFetcher, err := fetchers.NewInfoFetcher(fetchers.CryptoidProvider, "BCH", clientMock, nil)
+	require.NoError(t, err)
+
+	var balance float64
+	var wg sync.WaitGroup
+	wg.Add(2)
+	infoFetcher.FetchBalance([]string{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"}, "", &balance, &err, &wg)
+	require.NoError(t, err)
+	require.Equal(t, 1.5, balance)
+
+	infoFetcher.FetchBalance([]string{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6c"}, "", &balance, &err, &wg)
+	require.EqualError(t, err, "invalid address bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6c: invalid checksum")
+	wg.Wait()
+
+	clientMock.AssertExpectations(t)
+}
@@ -0,0 +1,49 @@
+package fetchers
+
+import "sync"
+
+// transactionBalanceFetcher is implemented by fetchers which can retrieve both the total balance and the recent transactions of addresses
+type transactionBalanceFetcher interface {
+	CryptoCurrencyBalanceFetcher
+	CryptoCurrencyTransactionFetcher
+}
+
+// fetchDetailedBalanceFromTransactions splits the total balance reported by `fetcher` into confirmed and pending amounts,
+// using the confirmations of the recent transactions of the addresses
+func fetchDetailedBalanceFromTransactions(fetcher transactionBalanceFetcher, addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = DetailedBalance{}
+
+	var total float64
+	var transactions []*Transaction
+	var balanceErr, transactionsErr error
+
+	infoFetched := sync.WaitGroup{}
+	infoFetched.Add(2)
+	go fetcher.FetchBalance(addresses, apiKey, &total, &balanceErr, &infoFetched)
+	go fetcher.FetchTransactions(addresses, apiKey, &transactions, &transactionsErr, &infoFetched)
+	infoFetched.Wait()
+
+	if *err = balanceErr; *err == nil {
+		*err = transactionsErr
+	}
+	if *err != nil {
+		return
+	}
+
+	for _, tx := range transactions {
+		if tx.Confirmations >= minConfirmations {
+			continue
+		}
+
+		if amount := tx.NetAmount(); amount >= 0 {
+			balance.PendingIncoming += amount
+		} else {
+			balance.PendingOutgoing -= amount
+		}
+	}
+
+	// The total balance includes both confirmed and pending funds
+	balance.Confirmed = total - balance.PendingIncoming + balance.PendingOutgoing
+}
@@ -0,0 +1,195 @@
+package fetchers
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// electrumProtocolVersion is the Electrum protocol version negotiated with servers
+	electrumProtocolVersion = "1.4"
+
+	// electrumTimeout bounds the time spent connecting to a server and waiting for each response
+	electrumTimeout = 10 * time.Second
+)
+
+// electrumConnection is a connection to an Electrum server, which exchanges newline-delimited JSON-RPC messages
+type electrumConnection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// request sends a JSON-RPC request and decodes the result of the matching response into `result`, skipping notifications
+func (connection *electrumConnection) request(method string, params []interface{}, result interface{}) error {
+	connection.nextID++
+	id := connection.nextID
+
+	message, err := json.Marshal(&jsonRPCRequest{"2.0", id, method, params})
+	if err != nil {
+		return err
+	}
+
+	if err = connection.conn.SetDeadline(time.Now().Add(electrumTimeout)); err != nil {
+		return newError(UnavailableError, "", err)
+	}
+	if _, err = connection.conn.Write(append(message, '\n')); err != nil {
+		return newError(UnavailableError, "", err)
+	}
+
+	for {
+		line, err := connection.reader.ReadBytes('\n')
+		if err != nil {
+			return newError(UnavailableError, "", err)
+		}
+
+		var response struct {
+			jsonRPCResponse
+			ID *int `json:"id"`
+		}
+		if err = json.Unmarshal(line, &response); err != nil {
+			return newError(MalformedResponseError, "", err)
+		}
+		if response.ID == nil || *response.ID != id {
+			continue
+		}
+
+		return response.decode(result)
+	}
+}
+
+// electrumConnectionPool keeps connections to an Electrum server open for reuse
+type electrumConnectionPool struct {
+	server    string
+	tlsConfig *tls.Config
+
+	// slots limits the number of simultaneously open connections
+	slots chan struct{}
+
+	mutex  sync.Mutex
+	idle   []*electrumConnection
+	closed bool
+}
+
+// newElectrumConnectionPool creates a pool of at most `maxConnections` connections to `server` (host:port), using TLS if `tlsConfig` is not nil
+func newElectrumConnectionPool(server string, tlsConfig *tls.Config, maxConnections int) *electrumConnectionPool {
+	return &electrumConnectionPool{server: server, tlsConfig: tlsConfig, slots: make(chan struct{}, maxConnections)}
+}
+
+// get returns an idle connection, or opens a new one once fewer than the maximum number of connections are in use.
+// `reused` denotes that the connection was idle, in which case the server may have dropped it in the meantime
+func (pool *electrumConnectionPool) get() (connection *electrumConnection, reused bool, err error) {
+	pool.slots <- struct{}{}
+
+	pool.mutex.Lock()
+	if count := len(pool.idle); count > 0 {
+		connection = pool.idle[count-1]
+		pool.idle = pool.idle[:count-1]
+		pool.mutex.Unlock()
+
+		return connection, true, nil
+	}
+	pool.mutex.Unlock()
+
+	if connection, err = pool.dial(); err != nil {
+		<-pool.slots
+		return nil, false, err
+	}
+
+	return connection, false, nil
+}
+
+// put returns a connection to the pool, closing it instead if it failed or the pool was closed
+func (pool *electrumConnectionPool) put(connection *electrumConnection, err error) {
+	pool.mutex.Lock()
+	if err != nil || pool.closed {
+		connection.conn.Close()
+	} else {
+		pool.idle = append(pool.idle, connection)
+	}
+	pool.mutex.Unlock()
+
+	<-pool.slots
+}
+
+// close closes the idle connections of the pool. Connections in use are closed when they are returned
+func (pool *electrumConnectionPool) close() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for _, connection := range pool.idle {
+		connection.conn.Close()
+	}
+	pool.idle = nil
+	pool.closed = true
+}
+
+// dial opens a connection to the server and negotiates the protocol version
+func (pool *electrumConnectionPool) dial() (*electrumConnection, error) {
+	dialer := &net.Dialer{Timeout: electrumTimeout}
+
+	var conn net.Conn
+	var err error
+	if pool.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", pool.server, pool.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", pool.server)
+	}
+	if err != nil {
+		logger().Warn("electrum connection failed", "server", pool.server, "error", err)
+		return nil, newError(UnavailableError, "", err)
+	}
+	logger().Debug("electrum connection opened", "server", pool.server, "tls", pool.tlsConfig != nil)
+
+	connection := &electrumConnection{conn: conn, reader: bufio.NewReader(conn)}
+	if err = connection.request("server.version", []interface{}{"wallet-balance", electrumProtocolVersion}, nil); err != nil {
+		conn.Close()
+		return nil, errorf(UnavailableError, "electrum handshake with %s failed: %s", pool.server, err)
+	}
+
+	return connection, nil
+}
+
+// do runs `fn` on a pooled connection. Servers drop idle connections after a while,
+// so `fn` is retried once on a new connection if a reused one turns out to be closed
+func (pool *electrumConnectionPool) do(fn func(connection *electrumConnection) error) error {
+	connection, reused, err := pool.get()
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+	err = fn(connection)
+	if reused && isConnectionDropped(err) {
+		logger().Debug("electrum connection dropped by the server, redialing", "server", pool.server, "error", err)
+		connection.conn.Close()
+		if connection, err = pool.dial(); err != nil {
+			<-pool.slots
+			return err
+		}
+		err = fn(connection)
+	}
+	logger().Debug("electrum request completed", "server", pool.server, "latency", time.Since(started), "error", err)
+
+	var rpcErr *jsonRPCError
+	if errors.As(err, &rpcErr) {
+		// Errors reported by the server leave the connection usable
+		pool.put(connection, nil)
+	} else {
+		pool.put(connection, err)
+	}
+
+	return err
+}
+
+// isConnectionDropped returns true if `err` denotes that the server closed the connection
+func isConnectionDropped(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET)
+}
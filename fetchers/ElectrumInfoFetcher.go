@@ -0,0 +1,188 @@
+package fetchers
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/PombeirP/wallet-balance/address"
+)
+
+const (
+	// ElectrumProvider is the name under which ElectrumInfoFetcher is registered
+	ElectrumProvider = "electrum"
+
+	// defaultElectrumMaxConnections is the default number of simultaneous connections to an Electrum server
+	defaultElectrumMaxConnections = 2
+)
+
+// ElectrumOptions holds the options accepted by the electrum provider
+type ElectrumOptions struct {
+	// Server is the host:port of the Electrum server
+	Server string `json:"server,omitempty"`
+	// TLS enables TLS, as used on the usual port 50002
+	TLS bool `json:"tls,omitempty"`
+	// SkipVerify disables the verification of the server's TLS certificate, as many servers use self-signed certificates
+	SkipVerify bool `json:"skip_verify,omitempty"`
+	// MaxConnections is the maximum number of simultaneous connections to the server. Defaults to 2
+	MaxConnections int `json:"max_connections,omitempty"`
+}
+
+var (
+	electrumPoolsMutex sync.Mutex
+	electrumPools      = make(map[ElectrumOptions]*electrumConnectionPool)
+)
+
+// CloseElectrumConnections closes the idle connections to all Electrum servers, which are otherwise kept open for reuse.
+// Fetchers created afterwards open new connections
+func CloseElectrumConnections() {
+	electrumPoolsMutex.Lock()
+	defer electrumPoolsMutex.Unlock()
+
+	for options, pool := range electrumPools {
+		pool.close()
+		delete(electrumPools, options)
+	}
+}
+
+func init() {
+	RegisterProvider(ElectrumProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		var options ElectrumOptions
+		if err := decodeProviderOptions(ElectrumProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		return NewElectrumInfoFetcher(symbol, options)
+	})
+}
+
+// ElectrumInfoFetcher fetches balances from an Electrum server, which looks up addresses by script hash.
+// Connections are pooled per server and shared between fetchers. Electrum servers don't know about exchange rates, which must come from a price provider
+type ElectrumInfoFetcher struct {
+	network *address.Network
+	pool    *electrumConnectionPool
+}
+
+// NewElectrumInfoFetcher creates an instance of ElectrumInfoFetcher for `symbol` on the server described by `options`
+func NewElectrumInfoFetcher(symbol string, options ElectrumOptions) (*ElectrumInfoFetcher, error) {
+	network, ok := address.Networks[strings.ToUpper(symbol)]
+	if !ok {
+		return nil, fmt.Errorf("%s does not support %s", ElectrumProvider, symbol)
+	}
+	if options.Server == "" {
+		return nil, fmt.Errorf("%s requires a server option", ElectrumProvider)
+	}
+	if options.MaxConnections <= 0 {
+		options.MaxConnections = defaultElectrumMaxConnections
+	}
+
+	electrumPoolsMutex.Lock()
+	defer electrumPoolsMutex.Unlock()
+
+	pool, ok := electrumPools[options]
+	if !ok {
+		var tlsConfig *tls.Config
+		if options.TLS {
+			host, _, err := net.SplitHostPort(options.Server)
+			if err != nil {
+				return nil, err
+			}
+			tlsConfig = &tls.Config{ServerName: host, InsecureSkipVerify: options.SkipVerify}
+		}
+
+		pool = newElectrumConnectionPool(options.Server, tlsConfig, options.MaxConnections)
+		electrumPools[options] = pool
+	}
+
+	return &ElectrumInfoFetcher{network, pool}, nil
+}
+
+// electrumBalance holds the result of `blockchain.scripthash.get_balance`, in satoshis. Unconfirmed is the net change of the mempool transactions
+type electrumBalance struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+// fetchBalances retrieves the confirmed and unconfirmed balances of each of the provided addresses with `blockchain.scripthash.get_balance`
+func (fetcher *ElectrumInfoFetcher) fetchBalances(addresses []string) ([]*electrumBalance, error) {
+	scriptHashes := make([]string, len(addresses))
+	for index, addr := range addresses {
+		var hashErr error
+		if scriptHashes[index], hashErr = fetcher.network.ElectrumScriptHash(addr); hashErr != nil {
+			return nil, errorf(InvalidAddressError, "invalid address %s: %s", addr, hashErr)
+		}
+	}
+
+	balances := make([]*electrumBalance, len(scriptHashes))
+	err := fetcher.pool.do(func(connection *electrumConnection) error {
+		for index, scriptHash := range scriptHashes {
+			balances[index] = &electrumBalance{}
+			if err := connection.request("blockchain.scripthash.get_balance", []interface{}{scriptHash}, balances[index]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// FetchBalance retrieves the aggregate confirmed and unconfirmed balances of the provided addresses with `blockchain.scripthash.get_balance`
+func (fetcher *ElectrumInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	balances, fetchErr := fetcher.fetchBalances(addresses)
+	if *err = fetchErr; *err != nil {
+		return
+	}
+
+	var total int64
+	for _, addressBalance := range balances {
+		total += addressBalance.Confirmed + addressBalance.Unconfirmed
+	}
+	*balance = float64(total) / satoshi
+}
+
+// FetchDetailedBalance retrieves the aggregate confirmed balance of the provided addresses, along with the amounts pending in the mempool.
+// Electrum servers don't report how many confirmations funds have, so a `minConfirmations` above 1 is rejected
+func (fetcher *ElectrumInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = DetailedBalance{}
+
+	if minConfirmations > 1 {
+		*err = fmt.Errorf("%s only tells apart unconfirmed funds, min_confirmations must be 1", ElectrumProvider)
+		return
+	}
+
+	balances, fetchErr := fetcher.fetchBalances(addresses)
+	if *err = fetchErr; *err != nil {
+		return
+	}
+
+	for _, addressBalance := range balances {
+		balance.Confirmed += float64(addressBalance.Confirmed) / satoshi
+		if addressBalance.Unconfirmed >= 0 {
+			balance.PendingIncoming += float64(addressBalance.Unconfirmed) / satoshi
+		} else {
+			balance.PendingOutgoing -= float64(addressBalance.Unconfirmed) / satoshi
+		}
+	}
+}
+
+// FetchExchangeRate always fails, since Electrum servers don't provide exchange rates
+func (fetcher *ElectrumInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate = 0.
+	*err = errorf(UnsupportedCurrencyError, "%s does not provide exchange rates, please configure a price_provider", ElectrumProvider)
+
+	done.Done()
+}
@@ -0,0 +1,209 @@
+package fetchers_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeElectrumServer is an in-process Electrum server answering get_balance requests
+type fakeElectrumServer struct {
+	address     string
+	connections int32
+
+	mutex sync.Mutex
+	conns []net.Conn
+}
+
+// dropConnections closes the connections accepted so far, as servers do with idle connections
+func (server *fakeElectrumServer) dropConnections() {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for _, conn := range server.conns {
+		conn.Close()
+	}
+	server.conns = nil
+}
+
+// newFakeElectrumServer starts a fakeElectrumServer answering get_balance requests from `balances`, keyed by script hash
+func newFakeElectrumServer(t *testing.T, balances map[string][2]int64) *fakeElectrumServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeElectrumServer{address: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			atomic.AddInt32(&server.connections, 1)
+			server.mutex.Lock()
+			server.conns = append(server.conns, conn)
+			server.mutex.Unlock()
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				scanner := bufio.NewScanner(conn)
+				encoder := json.NewEncoder(conn)
+				for scanner.Scan() {
+					var request struct {
+						ID     int      `json:"id"`
+						Method string   `json:"method"`
+						Params []string `json:"params"`
+					}
+					if json.Unmarshal(scanner.Bytes(), &request) != nil {
+						return
+					}
+
+					// Servers may interleave notifications with responses
+					encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "method": "blockchain.headers.subscribe", "params": []interface{}{}})
+
+					switch request.Method {
+					case "server.version":
+						encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": []string{"FakeElectrum 1.0", "1.4"}})
+					case "blockchain.scripthash.get_balance":
+						if balance, ok := balances[request.Params[0]]; ok {
+							encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "result": map[string]int64{"confirmed": balance[0], "unconfirmed": balance[1]}})
+						} else {
+							encoder.Encode(map[string]interface{}{"jsonrpc": "2.0", "id": request.ID, "error": map[string]interface{}{"code": 1, "message": "unknown script hash"}})
+						}
+					}
+				}
+			}(conn)
+		}
+	}()
+
+	return server
+}
+
+func TestElectrumInfoFetcherFetchBalance(t *testing.T) {
+	server := newFakeElectrumServer(t, map[string][2]int64{
+		// 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa
+		"8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161": {150000000, -10000000},
+	})
+
+	infoFetcher, err := fetchers.NewInfoFetcher(fetchers.ElectrumProvider, "BTC", nil, json.RawMessage(`{"server": "`+server.address+`"}`))
+	require.NoError(t, err)
+
+	cases := []struct {
+		addresses            []string
+		expectedBalance      float64
+		expectedErrorMessage string
+	}{
+		{[]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, 1.4, ""},
+		{[]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu"}, 0., "json-rpc error 1: unknown script hash"},
+		{[]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb"}, 0., "invalid address 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb: invalid checksum"},
+		{[]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, 1.4, ""},
+	}
+
+	for _, testCase := range cases {
+		var balance float64
+		var wg sync.WaitGroup
+		wg.Add(1)
+		infoFetcher.FetchBalance(testCase.addresses, "", &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, "%v", testCase.addresses)
+		} else {
+			require.NoError(t, err, "%v", testCase.addresses)
+			require.InDelta(t, testCase.expectedBalance, balance, 1e-9, "%v", testCase.addresses)
+		}
+	}
+
+	// The connection is kept open and reused across requests, even after an error reported by the server
+	require.Equal(t, int32(1), atomic.LoadInt32(&server.connections))
+}
+
+func TestElectrumInfoFetcherFetchDetailedBalance(t *testing.T) {
+	server := newFakeElectrumServer(t, map[string][2]int64{
+		// 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa
+		"8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161": {150000000, -10000000},
+		// 1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu
+		"71b6a00546326a622c2a484e88a81909706a0cce15009aa87fd9a6569ca84c93": {0, 20000000},
+	})
+
+	fetcher, err := fetchers.NewElectrumInfoFetcher("BTC", fetchers.ElectrumOptions{Server: server.address})
+	require.NoError(t, err)
+
+	cases := []struct {
+		minConfirmations     int
+		expectedBalance      fetchers.DetailedBalance
+		expectedErrorMessage string
+	}{
+		{1, fetchers.DetailedBalance{Confirmed: 1.5, PendingIncoming: 0.2, PendingOutgoing: 0.1}, ""},
+		{6, fetchers.DetailedBalance{}, "electrum only tells apart unconfirmed funds, min_confirmations must be 1"},
+	}
+
+	for _, testCase := range cases {
+		var balance fetchers.DetailedBalance
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchDetailedBalance([]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu"}, "", testCase.minConfirmations, &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage)
+		} else {
+			require.NoError(t, err)
+		}
+		require.InDelta(t, testCase.expectedBalance.Confirmed, balance.Confirmed, 1e-9, "confirmed with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingIncoming, balance.PendingIncoming, 1e-9, "pending incoming with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingOutgoing, balance.PendingOutgoing, 1e-9, "pending outgoing with %d confirmations", testCase.minConfirmations)
+	}
+}
+
+func TestElectrumInfoFetcherReconnects(t *testing.T) {
+	server := newFakeElectrumServer(t, map[string][2]int64{
+		// 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa
+		"8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161": {150000000, 0},
+	})
+
+	fetchBalance := func() (balance float64, err error) {
+		fetcher, err := fetchers.NewElectrumInfoFetcher("BTC", fetchers.ElectrumOptions{Server: server.address})
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalance([]string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa"}, "", &balance, &err, &wg)
+		wg.Wait()
+
+		return
+	}
+
+	balance, err := fetchBalance()
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, balance, 1e-9)
+
+	// An idle connection dropped by the server is replaced by a new one
+	server.dropConnections()
+	balance, err = fetchBalance()
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, balance, 1e-9)
+	require.Equal(t, int32(2), atomic.LoadInt32(&server.connections))
+
+	// Pools are created anew once closed
+	fetchers.CloseElectrumConnections()
+	balance, err = fetchBalance()
+	require.NoError(t, err)
+	require.InDelta(t, 1.5, balance, 1e-9)
+	require.Equal(t, int32(3), atomic.LoadInt32(&server.connections))
+}
+
+func TestNewElectrumInfoFetcher(t *testing.T) {
+	_, err := fetchers.NewElectrumInfoFetcher("ETH", fetchers.ElectrumOptions{Server: "127.0.0.1:50001"})
+	require.EqualError(t, err, "electrum does not support ETH")
+
+	_, err = fetchers.NewElectrumInfoFetcher("BTC", fetchers.ElectrumOptions{})
+	require.EqualError(t, err, "electrum requires a server option")
+}
@@ -0,0 +1,189 @@
+package fetchers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrorKind classifies the errors reported by fetchers, so that callers can tell a bad address from a rate limit or an outage
+type ErrorKind int
+
+const (
+	// UnknownError denotes an error which could not be classified
+	UnknownError ErrorKind = iota
+	// InvalidAddressError denotes an address rejected locally or by the provider
+	InvalidAddressError
+	// UnauthorizedError denotes missing, invalid or insufficient credentials such as an API key
+	UnauthorizedError
+	// RateLimitedError denotes a request rejected because too many requests were sent
+	RateLimitedError
+	// UnavailableError denotes a provider which could not be reached or failed to process the request
+	UnavailableError
+	// MalformedResponseError denotes a response which could not be parsed
+	MalformedResponseError
+	// UnsupportedCurrencyError denotes a crypto-currency or target currency which the provider doesn't support
+	UnsupportedCurrencyError
+)
+
+var errorKindNames = map[ErrorKind]string{
+	UnknownError:             "error",
+	InvalidAddressError:      "invalid address",
+	UnauthorizedError:        "unauthorized",
+	RateLimitedError:         "rate limited",
+	UnavailableError:         "provider unavailable",
+	MalformedResponseError:   "malformed response",
+	UnsupportedCurrencyError: "unsupported currency",
+}
+
+func (kind ErrorKind) String() string {
+	return errorKindNames[kind]
+}
+
+// redactedQueryParameters lists the (lower-case) query parameters whose values are hidden from the URLs recorded in errors
+var redactedQueryParameters = map[string]bool{"key": true, "apikey": true, "api_key": true, "token": true, "password": true, "secret": true}
+
+// Error is a classified error reported by a fetcher. Its message is the one of the underlying error
+type Error struct {
+	Kind ErrorKind
+	// Provider and Symbol identify the provider and crypto-currency the error relates to, when known
+	Provider string
+	Symbol   string
+	// URL is the requested URL, with credentials redacted. Empty if the error didn't occur while requesting a URL
+	URL string
+	// Err is the underlying error
+	Err error
+}
+
+func (err *Error) Error() string {
+	return err.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// newError returns an *Error of kind `kind` wrapping `err`, requested from `rawURL`
+func newError(kind ErrorKind, rawURL string, err error) *Error {
+	return &Error{Kind: kind, URL: redactURL(rawURL), Err: err}
+}
+
+// errorf returns an *Error of kind `kind` with a formatted message
+func errorf(kind ErrorKind, format string, args ...interface{}) *Error {
+	return &Error{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+// classify returns `err` as an *Error, using `kind` if it isn't one already
+func classify(kind ErrorKind, rawURL string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var typedErr *Error
+	if errors.As(err, &typedErr) {
+		return err
+	}
+
+	return newError(kind, rawURL, err)
+}
+
+// ErrorKindOf returns the kind of `err`. The kind of a *BatchError is the one shared by all its errors, or UnknownError if they differ
+func ErrorKindOf(err error) ErrorKind {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) && len(batchErr.Errors) > 0 {
+		kind := ErrorKindOf(batchErr.Errors[0])
+		for _, failure := range batchErr.Errors[1:] {
+			if ErrorKindOf(failure) != kind {
+				return UnknownError
+			}
+		}
+		return kind
+	}
+
+	var typedErr *Error
+	if errors.As(err, &typedErr) {
+		return typedErr.Kind
+	}
+
+	return UnknownError
+}
+
+// AnnotateError returns `err` as an *Error carrying `provider` and `symbol` (unless already set), classifying other errors as UnknownError
+func AnnotateError(err error, provider string, symbol string) error {
+	if err == nil {
+		return nil
+	}
+
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		for index, failure := range batchErr.Errors {
+			batchErr.Errors[index] = AnnotateError(failure, provider, symbol)
+		}
+		return err
+	}
+
+	var typedErr *Error
+	if !errors.As(err, &typedErr) {
+		return &Error{Kind: UnknownError, Provider: provider, Symbol: symbol, Err: err}
+	}
+
+	annotated := *typedErr
+	if annotated.Provider == "" {
+		annotated.Provider = provider
+	}
+	if annotated.Symbol == "" {
+		annotated.Symbol = symbol
+	}
+
+	return &annotated
+}
+
+// statusErrorKind classifies a non-successful HTTP status code, using the response body to recognize rejected addresses
+func statusErrorKind(statusCode int, body string) ErrorKind {
+	switch {
+	case (statusCode == 400 || statusCode == 404 || statusCode == 422) && strings.Contains(strings.ToLower(body), "address"):
+		return InvalidAddressError
+	case statusCode == 400 && strings.Contains(strings.ToLower(body), "invalid symbol"):
+		return UnsupportedCurrencyError
+	case statusCode == 401 || statusCode == 403:
+		return UnauthorizedError
+	case statusCode == 429:
+		return RateLimitedError
+	case statusCode >= 500:
+		return UnavailableError
+	default:
+		return UnknownError
+	}
+}
+
+// redactURL hides credentials from `rawURL`: the user info and the values of query parameters such as API keys
+func redactURL(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	if parsedURL.User != nil {
+		parsedURL.User = url.User("REDACTED")
+	}
+
+	query := parsedURL.Query()
+	redacted := false
+	for parameter := range query {
+		if redactedQueryParameters[strings.ToLower(parameter)] && query.Get(parameter) != "" {
+			query.Set(parameter, "REDACTED")
+			redacted = true
+		}
+	}
+	if redacted {
+		parsedURL.RawQuery = query.Encode()
+	}
+
+	return parsedURL.String()
+}
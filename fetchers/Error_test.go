@@ -0,0 +1,125 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebNumberFetcherErrorKinds(t *testing.T) {
+	cases := []struct {
+		returnedBody       string
+		returnedStatusCode int
+		returnedGetError   error
+		expectedKind       fetchers.ErrorKind
+	}{
+		{"Invalid address format", 400, nil, fetchers.InvalidAddressError},
+		{"Invalid API key", 401, nil, fetchers.UnauthorizedError},
+		{"Forbidden", 403, nil, fetchers.UnauthorizedError},
+		{"Too many requests", 429, nil, fetchers.RateLimitedError},
+		{"Bad gateway", 502, nil, fetchers.UnavailableError},
+		{"", 0, errors.New("connection refused"), fetchers.UnavailableError},
+		{"Not found", 404, nil, fetchers.UnknownError},
+		{"abc", 200, nil, fetchers.MalformedResponseError},
+	}
+
+	const specifiedURL = "https://chainz.cryptoid.info/ltc/api.dws?q=getbalance&key=secret123&a=LTC1"
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		var response *http.Response
+		if testCase.returnedGetError == nil {
+			response = &http.Response{StatusCode: testCase.returnedStatusCode, Body: ioutil.NopCloser(bytes.NewBufferString(testCase.returnedBody))}
+		}
+		clientMock.On("Get", specifiedURL).Return(response, testCase.returnedGetError).Once()
+
+		_, err := fetchers.NewWebNumberFetcher(clientMock).Fetch(specifiedURL)
+
+		require.Error(t, err, testCase.returnedBody)
+		require.Equal(t, testCase.expectedKind, fetchers.ErrorKindOf(err), testCase.returnedBody)
+		var fetchErr *fetchers.Error
+		require.True(t, errors.As(err, &fetchErr), testCase.returnedBody)
+		require.NotContains(t, fetchErr.URL, "secret123", testCase.returnedBody)
+		require.NotContains(t, err.Error(), "secret123", testCase.returnedBody)
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestEtherscanJSONFetcherErrorKinds(t *testing.T) {
+	cases := []struct {
+		returnedBody string
+		expectedKind fetchers.ErrorKind
+	}{
+		{`{"status":"0","message":"NOTOK","result":"Max rate limit reached"}`, fetchers.RateLimitedError},
+		{`{"status":"0","message":"NOTOK","result":"Invalid API Key"}`, fetchers.UnauthorizedError},
+		{`{"status":"0","message":"NOTOK","result":"Error! Invalid address format"}`, fetchers.InvalidAddressError},
+		{`{"status":"0","message":"NOTOK","result":"Error!"}`, fetchers.UnknownError},
+		{`{"status":"0","message":"Max calls per sec rate limit reached (5/sec)","result":null}`, fetchers.RateLimitedError},
+		{`<html>`, fetchers.MalformedResponseError},
+	}
+
+	const specifiedURL = "https://api.etherscan.io/api?module=account&action=balancemulti&address=0x1&apikey=secret123"
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		clientMock.On("Get", specifiedURL).Return(&http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(testCase.returnedBody))}, nil).Once()
+
+		var response struct {
+			Result []interface{} `json:"result"`
+		}
+		err := fetchers.NewEtherscanJSONFetcher(clientMock).Fetch(specifiedURL, &response)
+
+		require.Error(t, err, testCase.returnedBody)
+		require.Equal(t, testCase.expectedKind, fetchers.ErrorKindOf(err), testCase.returnedBody)
+		var fetchErr *fetchers.Error
+		if errors.As(err, &fetchErr) {
+			require.Equal(t, "https://api.etherscan.io/api?action=balancemulti&address=0x1&apikey=REDACTED&module=account", fetchErr.URL, testCase.returnedBody)
+		}
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestAnnotateError(t *testing.T) {
+	require.NoError(t, fetchers.AnnotateError(nil, "cryptoid", "LTC"))
+
+	plainErr := errors.New("boom")
+	annotated := fetchers.AnnotateError(plainErr, "cryptoid", "LTC")
+	var fetchErr *fetchers.Error
+	require.True(t, errors.As(annotated, &fetchErr))
+	require.Equal(t, fetchers.UnknownError, fetchErr.Kind)
+	require.Equal(t, "cryptoid", fetchErr.Provider)
+	require.Equal(t, "LTC", fetchErr.Symbol)
+	require.EqualError(t, annotated, "boom")
+	require.True(t, errors.Is(annotated, plainErr))
+
+	// Kinds and providers set by the fetchers are preserved
+	typedErr := &fetchers.Error{Kind: fetchers.RateLimitedError, Provider: "coingecko", Err: errors.New("slow down")}
+	annotated = fetchers.AnnotateError(typedErr, "median", "BTC")
+	require.True(t, errors.As(annotated, &fetchErr))
+	require.Equal(t, fetchers.RateLimitedError, fetchErr.Kind)
+	require.Equal(t, "coingecko", fetchErr.Provider)
+	require.Equal(t, "BTC", fetchErr.Symbol)
+}
+
+func TestErrorKindOfBatchError(t *testing.T) {
+	rateLimited := &fetchers.Error{Kind: fetchers.RateLimitedError, Err: errors.New("slow down")}
+	unavailable := &fetchers.Error{Kind: fetchers.UnavailableError, Err: errors.New("down")}
+
+	require.Equal(t, fetchers.RateLimitedError, fetchers.ErrorKindOf(&fetchers.BatchError{Errors: []error{rateLimited, rateLimited}, BatchCount: 3}))
+	require.Equal(t, fetchers.UnknownError, fetchers.ErrorKindOf(&fetchers.BatchError{Errors: []error{rateLimited, unavailable}, BatchCount: 3}))
+	require.Equal(t, fetchers.UnknownError, fetchers.ErrorKindOf(errors.New("boom")))
+
+	batchErr := fetchers.AnnotateError(&fetchers.BatchError{Errors: []error{rateLimited, errors.New("boom")}, BatchCount: 2}, "blockchair", "BCH")
+	var annotatedBatch *fetchers.BatchError
+	require.True(t, errors.As(batchErr, &annotatedBatch))
+	for _, failure := range annotatedBatch.Errors {
+		var fetchErr *fetchers.Error
+		require.True(t, errors.As(failure, &fetchErr))
+		require.Equal(t, "blockchair", fetchErr.Provider)
+	}
+}
@@ -0,0 +1,236 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EsploraProvider is the name under which EsploraInfoFetcher is registered
+const EsploraProvider = "esplora"
+
+// esploraChainPageSize is the number of confirmed transactions returned per page by the /address/{address}/txs APIs
+const esploraChainPageSize = 25
+
+// esploraBaseURLs maps ticker symbols to the public Esplora instance used when the `base_url` option is omitted
+var esploraBaseURLs = map[string]string{
+	"BTC":  "https://blockstream.info/api",
+	"LBTC": "https://blockstream.info/liquid/api",
+}
+
+// esploraOptions holds the options accepted by the esplora provider
+type esploraOptions struct {
+	// BaseURL is the root URL of the Esplora REST API, e.g. https://mempool.space/api or a self-hosted instance
+	BaseURL string `json:"base_url,omitempty"`
+}
+
+func init() {
+	RegisterProvider(EsploraProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		options := esploraOptions{BaseURL: esploraBaseURLs[strings.ToUpper(symbol)]}
+		if err := decodeProviderOptions(EsploraProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+		if options.BaseURL == "" {
+			return nil, fmt.Errorf("%s requires a base_url option for %s", EsploraProvider, symbol)
+		}
+
+		return NewEsploraInfoFetcher(options.BaseURL, client), nil
+	})
+}
+
+// EsploraInfoFetcher fetches the balances of BTC or Liquid addresses from an Esplora REST API (Blockstream, mempool.space or self-hosted).
+// Esplora doesn't know about exchange rates, which must come from a price provider
+type EsploraInfoFetcher struct {
+	baseURL     string
+	apiFetcher  NumberFetcher
+	jsonFetcher JSONFetcher
+}
+
+// NewEsploraInfoFetcher creates an instance of EsploraInfoFetcher for the instance at `baseURL` from an HTTP client instance
+func NewEsploraInfoFetcher(baseURL string, client HTTPClient) *EsploraInfoFetcher {
+	return &EsploraInfoFetcher{strings.TrimSuffix(baseURL, "/"), NewWebNumberFetcher(client), NewWebJSONFetcher(client)}
+}
+
+// esploraStats holds the funded and spent totals of an address, either in the chain or in the mempool
+type esploraStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+}
+
+// esploraAddress holds the fields of an /address/{address} response
+type esploraAddress struct {
+	ChainStats   esploraStats `json:"chain_stats"`
+	MempoolStats esploraStats `json:"mempool_stats"`
+}
+
+// fetchAddressStats retrieves the chain and mempool stats of each of the provided addresses
+func (fetcher *EsploraInfoFetcher) fetchAddressStats(addresses []string) ([]*esploraAddress, error) {
+	stats := make([]*esploraAddress, len(addresses))
+	for index, address := range addresses {
+		stats[index] = &esploraAddress{}
+		if err := fetcher.jsonFetcher.Fetch(fmt.Sprintf("%s/address/%s", fetcher.baseURL, address), stats[index]); err != nil {
+			return nil, err
+		}
+	}
+
+	return stats, nil
+}
+
+// FetchBalance retrieves the aggregate balances of the provided addresses, including unconfirmed funds in the mempool
+func (fetcher *EsploraInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	stats, fetchErr := fetcher.fetchAddressStats(addresses)
+	if *err = fetchErr; *err != nil {
+		return
+	}
+
+	var total int64
+	for _, addressStats := range stats {
+		total += addressStats.ChainStats.FundedTxoSum - addressStats.ChainStats.SpentTxoSum
+		total += addressStats.MempoolStats.FundedTxoSum - addressStats.MempoolStats.SpentTxoSum
+	}
+	*balance = float64(total) / satoshi
+}
+
+// FetchExchangeRate always fails, since Esplora doesn't provide exchange rates
+func (fetcher *EsploraInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate = 0.
+	*err = errorf(UnsupportedCurrencyError, "%s does not provide exchange rates, please configure a price_provider", EsploraProvider)
+
+	done.Done()
+}
+
+// FetchDetailedBalance retrieves the aggregate confirmed and pending balances of the provided addresses.
+// With a single required confirmation the chain and mempool stats are used directly, otherwise the recent transactions are inspected
+func (fetcher *EsploraInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	if minConfirmations > 1 {
+		fetchDetailedBalanceFromTransactions(fetcher, addresses, apiKey, minConfirmations, balance, err, done)
+		return
+	}
+
+	defer done.Done()
+
+	*balance = DetailedBalance{}
+
+	stats, fetchErr := fetcher.fetchAddressStats(addresses)
+	if *err = fetchErr; *err != nil {
+		return
+	}
+
+	for _, addressStats := range stats {
+		balance.Confirmed += float64(addressStats.ChainStats.FundedTxoSum-addressStats.ChainStats.SpentTxoSum) / satoshi
+		balance.PendingIncoming += float64(addressStats.MempoolStats.FundedTxoSum) / satoshi
+		balance.PendingOutgoing += float64(addressStats.MempoolStats.SpentTxoSum) / satoshi
+	}
+}
+
+// esploraOutput holds the fields of a transaction output returned by the /address/{address}/txs API
+type esploraOutput struct {
+	ScriptPubKeyAddress string `json:"scriptpubkey_address"`
+	Value               int64  `json:"value"`
+}
+
+// esploraTransaction holds the fields of a transaction returned by the /address/{address}/txs API
+type esploraTransaction struct {
+	TxID   string `json:"txid"`
+	Fee    int64  `json:"fee"`
+	Status struct {
+		Confirmed   bool  `json:"confirmed"`
+		BlockHeight int   `json:"block_height"`
+		BlockTime   int64 `json:"block_time"`
+	} `json:"status"`
+	Vin []struct {
+		PrevOut *esploraOutput `json:"prevout"`
+	} `json:"vin"`
+	Vout []*esploraOutput `json:"vout"`
+}
+
+// FetchTransactions retrieves the most recent transactions of the provided addresses (the mempool and the last 25 confirmed transactions of each address)
+func (fetcher *EsploraInfoFetcher) FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, false)
+}
+
+// FetchTransactionHistory retrieves all the transactions of the provided addresses, paging through their confirmed transactions 25 at a time
+func (fetcher *EsploraInfoFetcher) FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, true)
+}
+
+// fetchTransactions retrieves the mempool and the last confirmed transactions of the provided addresses, or all their confirmed transactions if `allPages` is set
+func (fetcher *EsploraInfoFetcher) fetchTransactions(addresses []string, allPages bool) ([]*Transaction, error) {
+	tipHeight, err := fetcher.apiFetcher.Fetch(fmt.Sprintf("%s/blocks/tip/height", fetcher.baseURL))
+	if err != nil {
+		return nil, err
+	}
+
+	queried := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		queried[address] = true
+	}
+
+	set := newTransactionSet()
+	seen := make(map[string]bool)
+	for _, address := range addresses {
+		// The first page holds the mempool transactions and the first confirmed ones, the next pages continue after the last confirmed transaction seen
+		url := fmt.Sprintf("%s/address/%s/txs", fetcher.baseURL, address)
+		for url != "" {
+			var rawTxs []*esploraTransaction
+			if err = fetcher.jsonFetcher.Fetch(url, &rawTxs); err != nil {
+				return nil, err
+			}
+
+			url = ""
+			confirmed, lastConfirmedTxID := 0, ""
+			for _, rawTx := range rawTxs {
+				if rawTx.Status.Confirmed {
+					confirmed, lastConfirmedTxID = confirmed+1, rawTx.TxID
+				}
+
+				// A transaction involving several queried addresses is returned once per address
+				if seen[rawTx.TxID] {
+					continue
+				}
+				seen[rawTx.TxID] = true
+
+				fetcher.addTransaction(set, queried, rawTx, int(tipHeight))
+			}
+
+			if allPages && confirmed == esploraChainPageSize {
+				url = fmt.Sprintf("%s/address/%s/txs/chain/%s", fetcher.baseURL, address, lastConfirmedTxID)
+			}
+		}
+	}
+
+	return set.sorted(), nil
+}
+
+// addTransaction adds the movements of the queried addresses in `rawTx` to the transaction set
+func (fetcher *EsploraInfoFetcher) addTransaction(set *transactionSet, queried map[string]bool, rawTx *esploraTransaction, tipHeight int) {
+	tx := set.get(rawTx.TxID)
+	if rawTx.Status.Confirmed {
+		tx.Time = time.Unix(rawTx.Status.BlockTime, 0)
+		tx.Confirmations = tipHeight - rawTx.Status.BlockHeight + 1
+	} else {
+		tx.Time = time.Now()
+	}
+
+	for _, input := range rawTx.Vin {
+		if input.PrevOut != nil && queried[input.PrevOut.ScriptPubKeyAddress] {
+			tx.movement(input.PrevOut.ScriptPubKeyAddress).Out += float64(input.PrevOut.Value) / satoshi
+			tx.Fee = float64(rawTx.Fee) / satoshi
+		}
+	}
+	for _, output := range rawTx.Vout {
+		if queried[output.ScriptPubKeyAddress] {
+			tx.movement(output.ScriptPubKeyAddress).In += float64(output.Value) / satoshi
+		}
+	}
+}
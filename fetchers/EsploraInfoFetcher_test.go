@@ -0,0 +1,155 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+var esploraResponses = map[string]string{
+	"https://esplora.local/api/address/a":         `{"address":"a","chain_stats":{"funded_txo_sum":300000000,"spent_txo_sum":100000000},"mempool_stats":{"funded_txo_sum":50000000,"spent_txo_sum":0}}`,
+	"https://esplora.local/api/address/b":         `{"address":"b","chain_stats":{"funded_txo_sum":100000000,"spent_txo_sum":0},"mempool_stats":{"funded_txo_sum":0,"spent_txo_sum":20000000}}`,
+	"https://esplora.local/api/blocks/tip/height": "1000",
+	"https://esplora.local/api/address/a/txs": `[
+		{"txid":"tx3","fee":1000,"status":{"confirmed":false},"vin":[{"prevout":{"scriptpubkey_address":"c","value":50001000}}],"vout":[{"scriptpubkey_address":"a","value":50000000}]},
+		{"txid":"tx2","fee":2000,"status":{"confirmed":true,"block_height":999,"block_time":1500000100},"vin":[{"prevout":{"scriptpubkey_address":"a","value":100002000}}],"vout":[{"scriptpubkey_address":"d","value":100000000}]},
+		{"txid":"tx1","fee":1000,"status":{"confirmed":true,"block_height":900,"block_time":1500000000},"vin":[{"prevout":{"scriptpubkey_address":"c","value":300001000}}],"vout":[{"scriptpubkey_address":"a","value":300000000}]}]`,
+	"https://esplora.local/api/address/b/txs": `[
+		{"txid":"tx4","fee":1000,"status":{"confirmed":false},"vin":[{"prevout":{"scriptpubkey_address":"b","value":100000000}}],"vout":[{"scriptpubkey_address":"e","value":20000000},{"scriptpubkey_address":"b","value":79999000}]},
+		{"txid":"tx0","fee":1000,"status":{"confirmed":true,"block_height":500,"block_time":1400000000},"vin":[{"prevout":{"scriptpubkey_address":"c","value":100001000}}],"vout":[{"scriptpubkey_address":"b","value":100000000}]}]`,
+}
+
+func newEsploraClientMock(urls ...string) *mockHTTPClient {
+	clientMock := new(mockHTTPClient)
+	for _, url := range urls {
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(esploraResponses[url]))}, nil).Once()
+	}
+
+	return clientMock
+}
+
+func TestEsploraInfoFetcherFetchBalance(t *testing.T) {
+	clientMock := newEsploraClientMock("https://esplora.local/api/address/a", "https://esplora.local/api/address/b")
+	fetcher := fetchers.NewEsploraInfoFetcher("https://esplora.local/api/", clientMock)
+
+	var balance float64
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchBalance([]string{"a", "b"}, "", &balance, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.InDelta(t, 3.3, balance, 1e-9)
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestEsploraInfoFetcherFetchDetailedBalance(t *testing.T) {
+	cases := []struct {
+		minConfirmations int
+		urls             []string
+		expectedBalance  fetchers.DetailedBalance
+	}{
+		{1, []string{"https://esplora.local/api/address/a", "https://esplora.local/api/address/b"},
+			fetchers.DetailedBalance{Confirmed: 3., PendingIncoming: 0.5, PendingOutgoing: 0.2}},
+		{6, []string{"https://esplora.local/api/address/a", "https://esplora.local/api/address/b", "https://esplora.local/api/blocks/tip/height",
+			"https://esplora.local/api/address/a/txs", "https://esplora.local/api/address/b/txs"},
+			fetchers.DetailedBalance{Confirmed: 4.00003, PendingIncoming: 0.5, PendingOutgoing: 1.00002 + 0.20001}},
+	}
+
+	for _, testCase := range cases {
+		clientMock := newEsploraClientMock(testCase.urls...)
+		fetcher := fetchers.NewEsploraInfoFetcher("https://esplora.local/api", clientMock)
+
+		var balance fetchers.DetailedBalance
+		var err error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchDetailedBalance([]string{"a", "b"}, "", testCase.minConfirmations, &balance, &err, &wg)
+		wg.Wait()
+
+		require.NoError(t, err)
+		require.InDelta(t, testCase.expectedBalance.Confirmed, balance.Confirmed, 1e-9, "confirmed balance with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingIncoming, balance.PendingIncoming, 1e-9, "pending incoming with %d confirmations", testCase.minConfirmations)
+		require.InDelta(t, testCase.expectedBalance.PendingOutgoing, balance.PendingOutgoing, 1e-9, "pending outgoing with %d confirmations", testCase.minConfirmations)
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestEsploraInfoFetcherFetchTransactions(t *testing.T) {
+	clientMock := newEsploraClientMock("https://esplora.local/api/blocks/tip/height", "https://esplora.local/api/address/a/txs", "https://esplora.local/api/address/b/txs")
+	fetcher := fetchers.NewEsploraInfoFetcher("https://esplora.local/api", clientMock)
+
+	var transactions []*fetchers.Transaction
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchTransactions([]string{"a", "b"}, "", &transactions, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 5)
+
+	byID := make(map[string]*fetchers.Transaction)
+	for _, tx := range transactions {
+		byID[tx.TxID] = tx
+	}
+
+	require.Equal(t, 2, byID["tx2"].Confirmations)
+	require.Equal(t, time.Unix(1500000100, 0), byID["tx2"].Time)
+	require.InDelta(t, -1.00002, byID["tx2"].NetAmount(), 1e-9)
+	require.InDelta(t, 0.00002, byID["tx2"].Fee, 1e-12)
+	require.Equal(t, 0, byID["tx3"].Confirmations)
+	require.Equal(t, 0., byID["tx3"].Fee)
+	require.InDelta(t, -0.20001, byID["tx4"].NetAmount(), 1e-9)
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestEsploraInfoFetcherFetchTransactionHistory(t *testing.T) {
+	// A mempool transaction and a full page of confirmed transactions each receiving 1000 satoshis, followed by a page with the oldest confirmed transaction
+	firstPageTxs := []string{`{"txid":"pending","fee":1000,"status":{"confirmed":false},"vin":[],"vout":[{"scriptpubkey_address":"a","value":1000}]}`}
+	for index := 0; index < 25; index++ {
+		firstPageTxs = append(firstPageTxs, fmt.Sprintf(`{"txid":"tx%d","fee":1000,"status":{"confirmed":true,"block_height":%d,"block_time":%d},"vin":[],"vout":[{"scriptpubkey_address":"a","value":1000}]}`, index, 900-index, 1500000200-index))
+	}
+	responses := map[string]string{
+		"https://esplora.local/api/blocks/tip/height":        "1000",
+		"https://esplora.local/api/address/a/txs":            "[" + strings.Join(firstPageTxs, ",") + "]",
+		"https://esplora.local/api/address/a/txs/chain/tx24": `[{"txid":"first","fee":1000,"status":{"confirmed":true,"block_height":100,"block_time":1400000000},"vin":[],"vout":[{"scriptpubkey_address":"a","value":100000000}]}]`,
+	}
+
+	clientMock := new(mockHTTPClient)
+	for url, body := range responses {
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+	}
+
+	fetcher := fetchers.NewEsploraInfoFetcher("https://esplora.local/api", clientMock)
+
+	var transactions []*fetchers.Transaction
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchTransactionHistory([]string{"a"}, "", &transactions, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 27)
+	total := 0.
+	for _, tx := range transactions {
+		total += tx.NetAmount()
+	}
+	require.InDelta(t, 1.00026, total, 1e-9)
+	require.Equal(t, "first", transactions[26].TxID)
+
+	clientMock.AssertExpectations(t)
+}
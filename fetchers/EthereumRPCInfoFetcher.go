@@ -0,0 +1,157 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+const (
+	// EthereumRPCProvider is the name under which EthereumRPCInfoFetcher is registered
+	EthereumRPCProvider = "ethereum-rpc"
+
+	// Selectors of the ERC-20 functions called with eth_call
+	erc20BalanceOfSelector = "0x70a08231"
+	erc20DecimalsSelector  = "0x313ce567"
+
+	// etherDecimals is the number of decimals of ether, i.e. 1 ETH = 10^18 wei
+	etherDecimals = 18
+)
+
+// ethereumRPCOptions holds the options accepted by the ethereum-rpc provider
+type ethereumRPCOptions struct {
+	JSONRPCOptions
+	// Token is the address of an ERC-20 contract whose token balance is fetched instead of the ether balance. Optional
+	Token string `json:"token,omitempty"`
+	// Decimals is the number of decimals of the token. Queried from the contract when omitted
+	Decimals *int `json:"decimals,omitempty"`
+}
+
+func init() {
+	RegisterProvider(EthereumRPCProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		var options ethereumRPCOptions
+		if err := decodeProviderOptions(EthereumRPCProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		if options.Token == "" {
+			return NewEthereumRPCInfoFetcher(options.JSONRPCOptions, client)
+		}
+		return NewERC20RPCInfoFetcher(options.JSONRPCOptions, options.Token, options.Decimals, client)
+	})
+}
+
+// EthereumRPCInfoFetcher fetches the ether or ERC-20 token balances of addresses from an Ethereum node over standard JSON-RPC.
+// Nodes don't know about exchange rates, which must come from a price provider
+type EthereumRPCInfoFetcher struct {
+	rpcClient *jsonRPCClient
+	token     string
+	decimals  *int
+}
+
+// NewEthereumRPCInfoFetcher creates an instance of EthereumRPCInfoFetcher fetching ether balances from the node described by `options`
+func NewEthereumRPCInfoFetcher(options JSONRPCOptions, client HTTPClient) (*EthereumRPCInfoFetcher, error) {
+	decimals := etherDecimals
+	return NewERC20RPCInfoFetcher(options, "", &decimals, client)
+}
+
+// NewERC20RPCInfoFetcher creates an instance of EthereumRPCInfoFetcher fetching the balances of the ERC-20 token at address `token`
+// from the node described by `options`. If `decimals` is nil, the number of decimals is queried from the contract
+func NewERC20RPCInfoFetcher(options JSONRPCOptions, token string, decimals *int, client HTTPClient) (*EthereumRPCInfoFetcher, error) {
+	rpcClient, err := newJSONRPCClient(EthereumRPCProvider, client, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EthereumRPCInfoFetcher{rpcClient, token, decimals}, nil
+}
+
+// FetchRawBalance retrieves the exact aggregate balance of the provided addresses in the smallest unit (wei for ether), using a single batched request
+func (fetcher *EthereumRPCInfoFetcher) FetchRawBalance(addresses []string) (balance *big.Int, decimals int, err error) {
+	results := make([]string, len(addresses))
+	calls := make([]*jsonRPCCall, len(addresses), len(addresses)+1)
+	for index, address := range addresses {
+		if fetcher.token == "" {
+			calls[index] = &jsonRPCCall{"eth_getBalance", []interface{}{address, "latest"}, &results[index]}
+		} else {
+			data := erc20BalanceOfSelector + fmt.Sprintf("%064s", strings.TrimPrefix(strings.ToLower(address), "0x"))
+			calls[index] = &jsonRPCCall{"eth_call", []interface{}{map[string]string{"to": fetcher.token, "data": data}, "latest"}, &results[index]}
+		}
+	}
+
+	var decimalsResult string
+	if fetcher.decimals == nil {
+		calls = append(calls, &jsonRPCCall{"eth_call", []interface{}{map[string]string{"to": fetcher.token, "data": erc20DecimalsSelector}, "latest"}, &decimalsResult})
+	}
+
+	if err = fetcher.rpcClient.batch(calls); err != nil {
+		return
+	}
+
+	if fetcher.decimals != nil {
+		decimals = *fetcher.decimals
+	} else {
+		var rawDecimals *big.Int
+		if rawDecimals, err = parseHexQuantity(decimalsResult); err != nil {
+			err = classify(MalformedResponseError, "", err)
+			return
+		}
+		decimals = int(rawDecimals.Int64())
+	}
+
+	balance = new(big.Int)
+	for index, result := range results {
+		partialBalance, parseErr := parseHexQuantity(result)
+		if parseErr != nil {
+			return nil, 0, errorf(MalformedResponseError, "invalid balance for %s: %s", addresses[index], parseErr)
+		}
+		balance.Add(balance, partialBalance)
+	}
+
+	return
+}
+
+// FetchBalance retrieves the aggregate balance of the provided addresses in whole coins (ether or tokens)
+func (fetcher *EthereumRPCInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	rawBalance, decimals, fetchErr := fetcher.FetchRawBalance(addresses)
+	if fetchErr != nil {
+		*err = fetchErr
+		return
+	}
+
+	unit := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+	*balance, _ = new(big.Float).Quo(new(big.Float).SetInt(rawBalance), unit).Float64()
+}
+
+// FetchExchangeRate always fails, since nodes don't provide exchange rates
+func (fetcher *EthereumRPCInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate = 0.
+	*err = errorf(UnsupportedCurrencyError, "%s does not provide exchange rates, please configure a price_provider", EthereumRPCProvider)
+
+	done.Done()
+}
+
+// parseHexQuantity parses a 0x-prefixed hexadecimal quantity or 32-byte word returned by an Ethereum node
+func parseHexQuantity(value string) (*big.Int, error) {
+	digits := strings.TrimPrefix(value, "0x")
+	if digits == value {
+		return nil, fmt.Errorf("invalid hex quantity %q", value)
+	}
+	if digits == "" {
+		// Calls to addresses without code return an empty result
+		return new(big.Int), nil
+	}
+
+	quantity, ok := new(big.Int).SetString(digits, 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid hex quantity %q", value)
+	}
+
+	return quantity, nil
+}
@@ -0,0 +1,93 @@
+package fetchers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEthereumRPCInfoFetcherFetchBalance(t *testing.T) {
+	const token = "0x00000000000000000000000000000000000000aa"
+
+	server := newFakeJSONRPCServer(t, "", "", func(method string, params []json.RawMessage) (interface{}, error) {
+		var address string
+		switch method {
+		case "eth_getBalance":
+			require.JSONEq(t, `"latest"`, string(params[1]))
+			require.NoError(t, json.Unmarshal(params[0], &address))
+			return map[string]string{
+				"0xa": "0xde0b6b3a7640001", // 1 ETH + 1 wei
+				"0xb": "0x6f05b59d3b20000", // 0.5 ETH
+				"0xc": "0x0",
+			}[address], nil
+		case "eth_call":
+			var call struct {
+				To   string `json:"to"`
+				Data string `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(params[0], &call))
+			require.Equal(t, token, call.To)
+			switch call.Data {
+			case "0x313ce567":
+				return "0x0000000000000000000000000000000000000000000000000000000000000006", nil
+			case "0x70a08231000000000000000000000000000000000000000000000000000000000000000a":
+				return "0x00000000000000000000000000000000000000000000000000000000004c4b40", nil // 5 tokens
+			case "0x70a08231000000000000000000000000000000000000000000000000000000000000000b":
+				return "0x", nil
+			}
+		}
+
+		return nil, errors.New("unexpected call")
+	})
+	defer server.Close()
+
+	six := 6
+	cases := []struct {
+		name                 string
+		token                string
+		decimals             *int
+		addresses            []string
+		expectedRawBalance   string
+		expectedBalance      float64
+		expectedErrorMessage string
+	}{
+		{"ether", "", nil, []string{"0xa", "0xb", "0xc"}, "1500000000000000001", 1.5, ""},
+		{"token with queried decimals", token, nil, []string{"0xa", "0xb"}, "5000000", 5., ""},
+		{"token with configured decimals", token, &six, []string{"0xA"}, "5000000", 5., ""},
+		{"call error", token, &six, []string{"0xd"}, "", 0., "json-rpc error -8: unexpected call"},
+	}
+
+	for _, testCase := range cases {
+		var fetcher *fetchers.EthereumRPCInfoFetcher
+		var err error
+		if testCase.token == "" {
+			fetcher, err = fetchers.NewEthereumRPCInfoFetcher(fetchers.JSONRPCOptions{URL: server.URL}, server.Client())
+		} else {
+			fetcher, err = fetchers.NewERC20RPCInfoFetcher(fetchers.JSONRPCOptions{URL: server.URL}, testCase.token, testCase.decimals, server.Client())
+		}
+		require.NoError(t, err, testCase.name)
+
+		rawBalance, _, err := fetcher.FetchRawBalance(testCase.addresses)
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+			continue
+		}
+		require.NoError(t, err, testCase.name)
+		expectedRawBalance, _ := new(big.Int).SetString(testCase.expectedRawBalance, 10)
+		require.Equal(t, 0, expectedRawBalance.Cmp(rawBalance), "%s: expected %s, got %s", testCase.name, expectedRawBalance, rawBalance)
+
+		var balance float64
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalance(testCase.addresses, "", &balance, &err, &wg)
+		wg.Wait()
+
+		require.NoError(t, err, testCase.name)
+		require.InDelta(t, testCase.expectedBalance, balance, 1e-12, testCase.name)
+	}
+}
@@ -1,25 +1,135 @@
 package fetchers
 
 import (
+	"encoding/json"
 	"fmt"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
 	wei = 1000000000000000000. // 10^18
+
+	// EtherscanProvider is the name under which EtherscanInfoFetcher is registered
+	EtherscanProvider = "etherscan"
+
+	// etherscanBatchSize is the maximum number of addresses accepted by the balancemulti action
+	etherscanBatchSize = 20
+
+	// etherscanPageSize is the number of transactions requested per page of the txlist action
+	etherscanPageSize = 100
 )
 
-// EtherscanInfoFetcher fetches the balance and exchange rate of Ethereum on https://api.etherscan.io/
+// EtherscanChain describes an EVM chain served by an Etherscan-compatible explorer API
+type EtherscanChain struct {
+	// BaseURL is the root URL of the explorer API, e.g. https://api.polygonscan.com
+	BaseURL string `json:"base_url,omitempty"`
+	// Symbol is the ticker symbol of the chain's native currency
+	Symbol string `json:"symbol,omitempty"`
+	// PriceAction is the `stats` module action returning the price of the native currency, e.g. "maticprice"
+	PriceAction string `json:"price_action,omitempty"`
+	// PriceField is the field of the price result holding the USD price, e.g. "maticusd"
+	PriceField string `json:"price_field,omitempty"`
+}
+
+// etherscanChains holds the built-in Etherscan-compatible chains, selected with the `chain` option
+var etherscanChains = map[string]EtherscanChain{
+	"ethereum": {"https://api.etherscan.io", "ETH", "ethprice", "ethusd"},
+	"polygon":  {"https://api.polygonscan.com", "MATIC", "maticprice", "maticusd"},
+	"bsc":      {"https://api.bscscan.com", "BNB", "bnbprice", "ethusd"},
+	"arbitrum": {"https://api.arbiscan.io", "ETH", "ethprice", "ethusd"},
+	"optimism": {"https://api-optimistic.etherscan.io", "ETH", "ethprice", "ethusd"},
+}
+
+// etherscanDefaultChains maps ticker symbols to the chain used when the `chain` option is omitted
+var etherscanDefaultChains = map[string]string{
+	"ETH":   "ethereum",
+	"MATIC": "polygon",
+	"BNB":   "bsc",
+}
+
+// etherscanOptions holds the options accepted by the etherscan provider. The fields of EtherscanChain override those of the selected chain
+type etherscanOptions struct {
+	// Chain is the name of a built-in chain ("ethereum", "polygon", "bsc", "arbitrum" or "optimism"). Defaults to the chain of the ticker symbol
+	Chain string `json:"chain,omitempty"`
+	EtherscanChain
+}
+
+func init() {
+	RegisterProvider(EtherscanProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		var options etherscanOptions
+		if err := decodeProviderOptions(EtherscanProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		chainName := options.Chain
+		if chainName == "" {
+			chainName = etherscanDefaultChains[strings.ToUpper(symbol)]
+		}
+		chain, ok := etherscanChains[chainName]
+		if !ok && options.BaseURL == "" {
+			if options.Chain != "" {
+				return nil, fmt.Errorf("unknown %s chain %s", EtherscanProvider, options.Chain)
+			}
+			return nil, fmt.Errorf("%s requires a chain or base_url option for %s", EtherscanProvider, symbol)
+		}
+
+		if options.BaseURL != "" {
+			chain.BaseURL = strings.TrimSuffix(options.BaseURL, "/")
+		}
+		if options.Symbol != "" {
+			chain.Symbol = options.Symbol
+		}
+		if options.PriceAction != "" {
+			chain.PriceAction = options.PriceAction
+		}
+		if options.PriceField != "" {
+			chain.PriceField = options.PriceField
+		}
+		if chain.Symbol == "" {
+			chain.Symbol = strings.ToUpper(symbol)
+		}
+
+		if !strings.EqualFold(symbol, chain.Symbol) {
+			return nil, fmt.Errorf("%s chain %s only supports %s, not %s", EtherscanProvider, chainName, chain.Symbol, symbol)
+		}
+
+		return NewEtherscanCompatibleInfoFetcher(chain, client), nil
+	})
+}
+
+// EtherscanAlternativeChain returns the built-in chain selected by the etherscan provider `rawOptions` when it isn't the default chain of `symbol`
+// (e.g. "arbitrum" for ETH), or an empty string otherwise
+func EtherscanAlternativeChain(symbol string, rawOptions json.RawMessage) string {
+	var options etherscanOptions
+	if len(rawOptions) == 0 || json.Unmarshal(rawOptions, &options) != nil {
+		return ""
+	}
+	if options.Chain == "" || options.Chain == etherscanDefaultChains[strings.ToUpper(symbol)] {
+		return ""
+	}
+
+	return options.Chain
+}
+
+// EtherscanInfoFetcher fetches the balance and exchange rate of the native currency of an EVM chain from an Etherscan-compatible explorer API,
+// such as https://api.etherscan.io/ for Ethereum
 type EtherscanInfoFetcher struct {
+	chain      EtherscanChain
 	apiFetcher JSONFetcher
 }
 
-// NewEtherscanInfoFetcher creates an instance of EtherscanInfoFetcher from an HTTP client instance
+// NewEtherscanInfoFetcher creates an instance of EtherscanInfoFetcher for Ethereum on https://api.etherscan.io/ from an HTTP client instance
 func NewEtherscanInfoFetcher(client HTTPClient) *EtherscanInfoFetcher {
+	return NewEtherscanCompatibleInfoFetcher(etherscanChains["ethereum"], client)
+}
+
+// NewEtherscanCompatibleInfoFetcher creates an instance of EtherscanInfoFetcher for the specified chain from an HTTP client instance
+func NewEtherscanCompatibleInfoFetcher(chain EtherscanChain, client HTTPClient) *EtherscanInfoFetcher {
 	apiFetcher := NewEtherscanJSONFetcher(client)
-	return &EtherscanInfoFetcher{apiFetcher}
+	return &EtherscanInfoFetcher{chain, apiFetcher}
 }
 
 type etherscanResponseHeader struct {
@@ -27,7 +137,7 @@ type etherscanResponseHeader struct {
 	Message string `json:"message,omitempty"`
 }
 
-// FetchBalance retrieves the balance for the specified addresses from https://api.etherscan.io/
+// FetchBalance retrieves the balance for the specified addresses from the explorer API
 func (fetcher *EtherscanInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
 	defer done.Done()
 
@@ -43,54 +153,251 @@ func (fetcher *EtherscanInfoFetcher) FetchBalance(addresses []string, apiKey str
 		Result []*etherscanAccountBalanceResult `json:"result,omitempty"`
 	}
 
-	url := fmt.Sprintf("https://api.etherscan.io/api?module=account&action=balancemulti&address=%s&tag=latest", strings.Join(addresses, ","))
-	response := &etherscanAccountBalanceResponse{}
-
-	*err = fetcher.apiFetcher.Fetch(url, response)
+	*balance, *err = fetchInBatches(addresses, etherscanBatchSize, func(batch []string) (batchBalance float64, err error) {
+		url := fmt.Sprintf("%s/api?module=account&action=balancemulti&address=%s&tag=latest", fetcher.chain.BaseURL, strings.Join(batch, ","))
+		response := &etherscanAccountBalanceResponse{}
+		if err = fetcher.apiFetcher.Fetch(url, response); err != nil {
+			return
+		}
 
-	if *err == nil {
 		for _, responseEntry := range response.Result {
 			partialBalance, errParse := strconv.ParseFloat(responseEntry.Balance, 64)
-			if errParse == nil {
-				*balance += partialBalance / wei
-			} else {
-				*err = errParse
-				break
+			if errParse != nil {
+				return 0, newError(MalformedResponseError, url, errParse)
 			}
+			batchBalance += partialBalance / wei
 		}
-	}
+
+		return
+	})
 }
 
-// FetchExchangeRate retrieves the exchange rate for ETH in `targetCurrency` from https://api.etherscan.io/
+// FetchExchangeRate retrieves the exchange rate for the chain's native currency in `targetCurrency` from the explorer API
 func (fetcher *EtherscanInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
 	defer done.Done()
 
 	*exchangeRate = 0.
 
 	if targetCurrency != "usd" {
-		*err = fmt.Errorf("%s is not supported as target currency for ETH, only USD at the moment", targetCurrency)
+		*err = errorf(UnsupportedCurrencyError, "%s is not supported as target currency for %s, only USD at the moment", targetCurrency, fetcher.chain.Symbol)
 		return
 	}
 
-	type etherscanEthPriceResult struct {
-		ETHUSD string `json:"ethusd,omitempty"`
-	}
-
-	type etherscanEthPriceResponse struct {
+	type etherscanPriceResponse struct {
 		etherscanResponseHeader
-		Result *etherscanEthPriceResult `json:"result,omitempty"`
+		Result map[string]string `json:"result,omitempty"`
 	}
-	response := &etherscanEthPriceResponse{}
+	response := &etherscanPriceResponse{}
 
-	url := fmt.Sprintf("https://api.etherscan.io/api?module=stats&action=ethprice&apikey=%s", apiKey)
+	url := fmt.Sprintf("%s/api?module=stats&action=%s&apikey=%s", fetcher.chain.BaseURL, fetcher.chain.PriceAction, apiKey)
 	*err = fetcher.apiFetcher.Fetch(url, response)
 
 	if *err == nil {
-		_exchangeRate, _err := strconv.ParseFloat(response.Result.ETHUSD, 64)
+		price, ok := response.Result[fetcher.chain.PriceField]
+		if !ok {
+			*err = errorf(MalformedResponseError, "missing %s in %s price response", fetcher.chain.PriceField, fetcher.chain.Symbol)
+			return
+		}
+
+		_exchangeRate, _err := strconv.ParseFloat(price, 64)
 		if _err != nil {
-			*err = _err
+			*err = newError(MalformedResponseError, url, _err)
 		} else {
 			*exchangeRate = _exchangeRate
 		}
 	}
 }
+
+// FetchBalanceAt retrieves the aggregate balance of the specified addresses at the last block mined before `at` from the explorer API
+func (fetcher *EtherscanInfoFetcher) FetchBalanceAt(addresses []string, apiKey string, at time.Time, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*balance = 0.
+
+	type etherscanStringResponse struct {
+		etherscanResponseHeader
+		Result string `json:"result,omitempty"`
+	}
+
+	blockResponse := &etherscanStringResponse{}
+	url := fmt.Sprintf("%s/api?module=block&action=getblocknobytime&timestamp=%d&closest=before&apikey=%s", fetcher.chain.BaseURL, at.Unix(), apiKey)
+	if *err = fetcher.apiFetcher.Fetch(url, blockResponse); *err != nil {
+		return
+	}
+
+	for _, address := range addresses {
+		balanceResponse := &etherscanStringResponse{}
+		url := fmt.Sprintf("%s/api?module=account&action=balancehistory&address=%s&blockno=%s&apikey=%s", fetcher.chain.BaseURL, address, blockResponse.Result, apiKey)
+		if *err = fetcher.apiFetcher.Fetch(url, balanceResponse); *err != nil {
+			return
+		}
+
+		partialBalance, errParse := strconv.ParseFloat(balanceResponse.Result, 64)
+		if errParse != nil {
+			*err = newError(MalformedResponseError, url, errParse)
+			return
+		}
+		*balance += partialBalance / wei
+	}
+}
+
+// FetchTransactions retrieves the most recent transactions of the specified addresses from the explorer API
+func (fetcher *EtherscanInfoFetcher) FetchTransactions(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, apiKey, false)
+}
+
+// FetchTransactionHistory retrieves all the transactions of the specified addresses from the explorer API, one page at a time.
+// The transfers made to or from the addresses by contracts (internal transactions) are included, without confirmations since the API doesn't report them
+func (fetcher *EtherscanInfoFetcher) FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*Transaction, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*transactions, *err = fetcher.fetchTransactions(addresses, apiKey, true)
+}
+
+// etherscanTransaction holds the fields of a transaction returned by the txlist action
+type etherscanTransaction struct {
+	Hash          string `json:"hash"`
+	TimeStamp     string `json:"timeStamp"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	Value         string `json:"value"`
+	GasUsed       string `json:"gasUsed"`
+	GasPrice      string `json:"gasPrice"`
+	Confirmations string `json:"confirmations"`
+	IsError       string `json:"isError"`
+}
+
+// etherscanInternalTransaction holds the fields of a transfer made by a contract, returned by the txlistinternal action
+type etherscanInternalTransaction struct {
+	Hash      string `json:"hash"`
+	TimeStamp string `json:"timeStamp"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Value     string `json:"value"`
+	IsError   string `json:"isError"`
+}
+
+// fetchTransactions retrieves the first page of transactions of each of the specified addresses, most recent first.
+// If `allPages` is set, all pages are retrieved along with the internal transactions
+func (fetcher *EtherscanInfoFetcher) fetchTransactions(addresses []string, apiKey string, allPages bool) ([]*Transaction, error) {
+	set := newTransactionSet()
+	for _, address := range addresses {
+		err := fetcher.fetchTransactionPages("txlist", address, apiKey, allPages, func(rawTx json.RawMessage) error {
+			var tx etherscanTransaction
+			if err := json.Unmarshal(rawTx, &tx); err != nil {
+				return err
+			}
+			return addEtherscanTransaction(set, address, &tx)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if !allPages {
+			continue
+		}
+		err = fetcher.fetchTransactionPages("txlistinternal", address, apiKey, true, func(rawTx json.RawMessage) error {
+			var tx etherscanInternalTransaction
+			if err := json.Unmarshal(rawTx, &tx); err != nil {
+				return err
+			}
+			return addEtherscanInternalTransaction(set, address, &tx)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return set.sorted(), nil
+}
+
+// fetchTransactionPages passes each transaction listed by the account `action` for `address` to `add`, most recent first.
+// Only the first page is retrieved unless `allPages` is set
+func (fetcher *EtherscanInfoFetcher) fetchTransactionPages(action string, address string, apiKey string, allPages bool, add func(rawTx json.RawMessage) error) error {
+	type etherscanTransactionListResponse struct {
+		etherscanResponseHeader
+		Result []json.RawMessage `json:"result,omitempty"`
+	}
+
+	for page := 1; ; page++ {
+		response := &etherscanTransactionListResponse{}
+		url := fmt.Sprintf("%s/api?module=account&action=%s&address=%s&page=%d&offset=%d&sort=desc&apikey=%s", fetcher.chain.BaseURL, action, address, page, etherscanPageSize, apiKey)
+		if err := fetcher.apiFetcher.Fetch(url, response); err != nil {
+			return err
+		}
+
+		for _, rawTx := range response.Result {
+			if err := add(rawTx); err != nil {
+				return newError(MalformedResponseError, url, err)
+			}
+		}
+
+		if !allPages || len(response.Result) < etherscanPageSize {
+			return nil
+		}
+	}
+}
+
+// addEtherscanTransaction adds the movement of `address` in `rawTx` to the transaction set
+func addEtherscanTransaction(set *transactionSet, address string, rawTx *etherscanTransaction) error {
+	numbers := make([]float64, 5)
+	for index, field := range []string{rawTx.TimeStamp, rawTx.Value, rawTx.GasUsed, rawTx.GasPrice, rawTx.Confirmations} {
+		var err error
+		if numbers[index], err = strconv.ParseFloat(field, 64); err != nil {
+			return err
+		}
+	}
+	timeStamp, value, gasUsed, gasPrice, confirmations := numbers[0], numbers[1], numbers[2], numbers[3], numbers[4]
+	if rawTx.IsError == "1" {
+		// Failed transactions don't transfer any value, but still pay for gas
+		value = 0
+	}
+
+	tx := set.get(rawTx.Hash)
+	tx.Time = time.Unix(int64(timeStamp), 0)
+	tx.Confirmations = int(confirmations)
+	if strings.EqualFold(rawTx.From, address) {
+		// The sender pays for gas on top of the value sent, just like the inputs of a UTXO transaction cover its fee
+		tx.Fee = gasUsed * gasPrice / wei
+		tx.movement(address).Out += value/wei + tx.Fee
+	}
+	if strings.EqualFold(rawTx.To, address) {
+		tx.movement(address).In += value / wei
+	}
+
+	return nil
+}
+
+// addEtherscanInternalTransaction adds the movement of `address` in the internal transaction `rawTx` to the transaction set.
+// Gas is paid by the sender of the enclosing transaction, which is listed by the txlist action
+func addEtherscanInternalTransaction(set *transactionSet, address string, rawTx *etherscanInternalTransaction) error {
+	timeStamp, err := strconv.ParseFloat(rawTx.TimeStamp, 64)
+	if err != nil {
+		return err
+	}
+	value, err := strconv.ParseFloat(rawTx.Value, 64)
+	if err != nil {
+		return err
+	}
+	if rawTx.IsError == "1" {
+		return nil
+	}
+
+	tx := set.get(rawTx.Hash)
+	tx.Time = time.Unix(int64(timeStamp), 0)
+	if strings.EqualFold(rawTx.From, address) {
+		tx.movement(address).Out += value / wei
+	}
+	if strings.EqualFold(rawTx.To, address) {
+		tx.movement(address).In += value / wei
+	}
+
+	return nil
+}
+
+// FetchDetailedBalance retrieves the aggregate confirmed and pending balances of the provided addresses from the explorer API
+func (fetcher *EtherscanInfoFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	fetchDetailedBalanceFromTransactions(fetcher, addresses, apiKey, minConfirmations, balance, err, done)
+}
@@ -0,0 +1,203 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEtherscanInfoFetcherFetchTransactions(t *testing.T) {
+	responses := map[string]string{
+		"https://api.etherscan.io/api?module=account&action=txlist&address=0xa&page=1&offset=100&sort=desc&apikey=key": `{"status":"1","message":"OK","result":[
+			{"hash":"0x4","timeStamp":"1500000300","from":"0xa","to":"0xe","value":"2000000000000000000","gasUsed":"50000","gasPrice":"1000000000","confirmations":"3","isError":"1"},
+			{"hash":"0x3","timeStamp":"1500000200","from":"0xa","to":"0xe","value":"500000000000000000","gasUsed":"21000","gasPrice":"2000000000","confirmations":"5","isError":"0"},
+			{"hash":"0x2","timeStamp":"1500000100","from":"0xa","to":"0xb","value":"1000000000000000000","gasUsed":"21000","gasPrice":"1000000000","confirmations":"10","isError":"0"},
+			{"hash":"0x1","timeStamp":"1500000000","from":"0xc","to":"0xa","value":"3000000000000000000","gasUsed":"21000","gasPrice":"1000000000","confirmations":"20","isError":"0"}]}`,
+		"https://api.etherscan.io/api?module=account&action=txlist&address=0xb&page=1&offset=100&sort=desc&apikey=key": `{"status":"1","message":"OK","result":[
+			{"hash":"0x2","timeStamp":"1500000100","from":"0xa","to":"0xb","value":"1000000000000000000","gasUsed":"21000","gasPrice":"1000000000","confirmations":"10","isError":"0"}]}`,
+		"https://api.etherscan.io/api?module=account&action=txlist&address=0xd&page=1&offset=100&sort=desc&apikey=key": `{"status":"0","message":"No transactions found","result":[]}`,
+	}
+
+	clientMock := new(mockHTTPClient)
+	for url, body := range responses {
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+	}
+
+	fetcher := fetchers.NewEtherscanInfoFetcher(clientMock)
+
+	var transactions []*fetchers.Transaction
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchTransactions([]string{"0xa", "0xb", "0xd"}, "key", &transactions, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 4)
+
+	// A failed transaction doesn't transfer its value, but still pays for gas
+	require.Equal(t, "0x4", transactions[0].TxID)
+	require.InDelta(t, 0.00005, transactions[0].Fee, 1e-12)
+	require.InDelta(t, -0.00005, transactions[0].NetAmount(), 1e-12)
+
+	// The gas paid by the sender is deducted on top of the value sent
+	require.Equal(t, "0x3", transactions[1].TxID)
+	require.InDelta(t, 0.000042, transactions[1].Fee, 1e-12)
+	require.InDelta(t, -0.500042, transactions[1].NetAmount(), 1e-12)
+
+	// Transfer between two queried addresses only costs the fee
+	require.Equal(t, "0x2", transactions[2].TxID)
+	require.Equal(t, time.Unix(1500000100, 0), transactions[2].Time)
+	require.Equal(t, 10, transactions[2].Confirmations)
+	require.InDelta(t, 0.000021, transactions[2].Fee, 1e-12)
+	require.Len(t, transactions[2].Movements, 2)
+	require.InDelta(t, -0.000021, transactions[2].NetAmount(), 1e-12)
+
+	require.Equal(t, "0x1", transactions[3].TxID)
+	require.Equal(t, 0., transactions[3].Fee)
+	require.InDelta(t, 3., transactions[3].NetAmount(), 1e-12)
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestEtherscanInfoFetcherFetchTransactionHistory(t *testing.T) {
+	// A full first page of transactions each receiving 0.01 ETH, followed by a page with the oldest transaction
+	firstPageTxs := make([]string, 100)
+	for index := range firstPageTxs {
+		firstPageTxs[index] = fmt.Sprintf(`{"hash":"0x%d","timeStamp":"%d","from":"0xc","to":"0xa","value":"10000000000000000","gasUsed":"21000","gasPrice":"1000000000","confirmations":"%d","isError":"0"}`, index, 1500000200-index, 10+index)
+	}
+	responses := map[string]string{
+		"https://api.etherscan.io/api?module=account&action=txlist&address=0xa&page=1&offset=100&sort=desc&apikey=key": `{"status":"1","message":"OK","result":[` + strings.Join(firstPageTxs, ",") + `]}`,
+		"https://api.etherscan.io/api?module=account&action=txlist&address=0xa&page=2&offset=100&sort=desc&apikey=key": `{"status":"1","message":"OK","result":[
+			{"hash":"0xfirst","timeStamp":"1400000000","from":"0xc","to":"0xa","value":"1000000000000000000","gasUsed":"21000","gasPrice":"1000000000","confirmations":"5000","isError":"0"}]}`,
+		// ETH received from contracts is only listed among the internal transactions
+		"https://api.etherscan.io/api?module=account&action=txlistinternal&address=0xa&page=1&offset=100&sort=desc&apikey=key": `{"status":"1","message":"OK","result":[
+			{"hash":"0xwithdrawal","timeStamp":"1500000300","from":"0xcontract","to":"0xa","value":"500000000000000000","isError":"0"},
+			{"hash":"0xreverted","timeStamp":"1500000250","from":"0xcontract","to":"0xa","value":"700000000000000000","isError":"1"}]}`,
+	}
+
+	clientMock := new(mockHTTPClient)
+	for url, body := range responses {
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+	}
+
+	fetcher := fetchers.NewEtherscanInfoFetcher(clientMock)
+
+	var transactions []*fetchers.Transaction
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchTransactionHistory([]string{"0xa"}, "key", &transactions, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Len(t, transactions, 102)
+	total := 0.
+	for _, tx := range transactions {
+		total += tx.NetAmount()
+	}
+	require.InDelta(t, 2.5, total, 1e-9)
+	require.Equal(t, "0xwithdrawal", transactions[0].TxID)
+	require.Equal(t, "0xfirst", transactions[101].TxID)
+
+	clientMock.AssertExpectations(t)
+}
+
+func TestEtherscanInfoFetcherCompatibleChains(t *testing.T) {
+	cases := []struct {
+		symbol          string
+		options         string
+		balanceURL      string
+		priceURL        string
+		priceBody       string
+		expectedBalance float64
+		expectedRate    float64
+	}{
+		{"ETH", "",
+			"https://api.etherscan.io/api?module=account&action=balancemulti&address=0xa,0xb&tag=latest",
+			"https://api.etherscan.io/api?module=stats&action=ethprice&apikey=key",
+			`{"status":"1","message":"OK","result":{"ethbtc":"0.05","ethusd":"3000.5"}}`, 1.5, 3000.5},
+		{"MATIC", "",
+			"https://api.polygonscan.com/api?module=account&action=balancemulti&address=0xa,0xb&tag=latest",
+			"https://api.polygonscan.com/api?module=stats&action=maticprice&apikey=key",
+			`{"status":"1","message":"OK","result":{"maticbtc":"0.00002","maticusd":"0.75"}}`, 1.5, 0.75},
+		{"ETH", `{"chain": "optimism"}`,
+			"https://api-optimistic.etherscan.io/api?module=account&action=balancemulti&address=0xa,0xb&tag=latest",
+			"https://api-optimistic.etherscan.io/api?module=stats&action=ethprice&apikey=key",
+			`{"status":"1","message":"OK","result":{"ethusd":"3001"}}`, 1.5, 3001},
+		{"FTM", `{"base_url": "https://api.ftmscan.com/", "price_action": "ftmprice", "price_field": "ftmusd"}`,
+			"https://api.ftmscan.com/api?module=account&action=balancemulti&address=0xa,0xb&tag=latest",
+			"https://api.ftmscan.com/api?module=stats&action=ftmprice&apikey=key",
+			`{"status":"1","message":"OK","result":{"ftmusd":"0.4"}}`, 1.5, 0.4},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		clientMock.On("Get", testCase.balanceURL).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(
+			`{"status":"1","message":"OK","result":[{"account":"0xa","balance":"1000000000000000000"},{"account":"0xb","balance":"500000000000000000"}]}`))}, nil).Once()
+		clientMock.On("Get", testCase.priceURL).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(testCase.priceBody))}, nil).Once()
+
+		infoFetcher, err := fetchers.NewInfoFetcher(fetchers.EtherscanProvider, testCase.symbol, clientMock, []byte(testCase.options))
+		require.NoError(t, err, testCase.symbol)
+
+		var balance, exchangeRate float64
+		var balanceErr, exchangeRateErr error
+		var wg sync.WaitGroup
+		wg.Add(2)
+		infoFetcher.FetchBalance([]string{"0xa", "0xb"}, "key", &balance, &balanceErr, &wg)
+		infoFetcher.FetchExchangeRate("key", "usd", &exchangeRate, &exchangeRateErr, &wg)
+		wg.Wait()
+
+		require.NoError(t, balanceErr, testCase.symbol)
+		require.NoError(t, exchangeRateErr, testCase.symbol)
+		require.InDelta(t, testCase.expectedBalance, balance, 1e-12, testCase.symbol)
+		require.Equal(t, testCase.expectedRate, exchangeRate, testCase.symbol)
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestEtherscanInfoFetcherFetchBalanceInBatches(t *testing.T) {
+	addresses := make([]string, 45)
+	for index := range addresses {
+		addresses[index] = fmt.Sprintf("0x%d", index)
+	}
+
+	clientMock := new(mockHTTPClient)
+	for start := 0; start < len(addresses); start += 20 {
+		end := start + 20
+		if end > len(addresses) {
+			end = len(addresses)
+		}
+
+		var results []string
+		for _, address := range addresses[start:end] {
+			results = append(results, fmt.Sprintf(`{"account":"%s","balance":"1000000000000000000"}`, address))
+		}
+		url := fmt.Sprintf("https://api.etherscan.io/api?module=account&action=balancemulti&address=%s&tag=latest", strings.Join(addresses[start:end], ","))
+		body := fmt.Sprintf(`{"status":"1","message":"OK","result":[%s]}`, strings.Join(results, ","))
+		clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+	}
+
+	fetcher := fetchers.NewEtherscanInfoFetcher(clientMock)
+
+	var balance float64
+	var err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchBalance(addresses, "", &balance, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.InDelta(t, 45., balance, 1e-9)
+
+	clientMock.AssertExpectations(t)
+}
@@ -0,0 +1,37 @@
+package fetchers
+
+import (
+	"sync"
+	"time"
+)
+
+// FixedBalanceFetcher reports a fixed, manually-entered balance, e.g. for funds held on an exchange or off-chain
+type FixedBalanceFetcher struct {
+	balance float64
+}
+
+// NewFixedBalanceFetcher creates an instance of FixedBalanceFetcher which always reports `balance`
+func NewFixedBalanceFetcher(balance float64) *FixedBalanceFetcher {
+	return &FixedBalanceFetcher{balance}
+}
+
+// FetchBalance reports the fixed balance, ignoring the provided addresses
+func (fetcher *FixedBalanceFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	*balance = fetcher.balance
+	*err = nil
+
+	done.Done()
+}
+
+// FetchBalanceAt reports the fixed balance, which is assumed to be valid at any point in time
+func (fetcher *FixedBalanceFetcher) FetchBalanceAt(addresses []string, apiKey string, at time.Time, balance *float64, err *error, done *sync.WaitGroup) {
+	fetcher.FetchBalance(addresses, apiKey, balance, err, done)
+}
+
+// FetchDetailedBalance reports the fixed balance as confirmed
+func (fetcher *FixedBalanceFetcher) FetchDetailedBalance(addresses []string, apiKey string, minConfirmations int, balance *DetailedBalance, err *error, done *sync.WaitGroup) {
+	*balance = DetailedBalance{Confirmed: fetcher.balance}
+	*err = nil
+
+	done.Done()
+}
@@ -0,0 +1,180 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// GenericHTTPProvider is the name under which GenericHTTPInfoFetcher is registered
+const GenericHTTPProvider = "http"
+
+// Response types supported by GenericHTTPInfoFetcher
+const (
+	// NumberResponse denotes a response whose body is a plain number
+	NumberResponse = "number"
+	// JSONResponse denotes a JSON response whose number is selected with a path expression
+	JSONResponse = "json"
+)
+
+// Batching modes supported by GenericHTTPInfoFetcher
+const (
+	// SingleAddressBatching issues one request per address and sums the results
+	SingleAddressBatching = "single"
+	// JoinedAddressesBatching issues a single request with all addresses joined by a separator
+	JoinedAddressesBatching = "joined"
+)
+
+// GenericHTTPOptions describes how GenericHTTPInfoFetcher retrieves balances and exchange rates.
+// URL templates may contain the placeholders {address}, {addresses}, {api_key}, {symbol} and {currency}.
+type GenericHTTPOptions struct {
+	// BalanceURL is the URL template used to fetch balances, using {address} in single mode or {addresses} in joined mode
+	BalanceURL string `json:"balance_url,omitempty"`
+	// BalanceResponse is either "number" (default) or "json"
+	BalanceResponse string `json:"balance_response,omitempty"`
+	// BalancePath selects the balance in a JSON response. A `*` segment sums all matching values
+	BalancePath string `json:"balance_path,omitempty"`
+	// Divisor converts the returned balance into whole coins (e.g. 100000000 for satoshis). Defaults to 1
+	Divisor float64 `json:"divisor,omitempty"`
+	// Batching is either "single" (default) or "joined"
+	Batching string `json:"batching,omitempty"`
+	// Separator joins the addresses in "joined" mode. Defaults to ","
+	Separator string `json:"separator,omitempty"`
+	// BatchSize is the maximum number of addresses joined in a single request in "joined" mode. Unlimited when zero
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// RateURL is the URL template used to fetch the exchange rate in {currency}. Optional
+	RateURL string `json:"rate_url,omitempty"`
+	// RateResponse is either "number" (default) or "json"
+	RateResponse string `json:"rate_response,omitempty"`
+	// RatePath selects the exchange rate in a JSON response
+	RatePath string `json:"rate_path,omitempty"`
+}
+
+// GenericHTTPInfoFetcher fetches the balance and exchange rate of a crypto-currency from web APIs described declaratively by GenericHTTPOptions
+type GenericHTTPInfoFetcher struct {
+	symbol        string
+	options       GenericHTTPOptions
+	numberFetcher NumberFetcher
+	jsonFetcher   JSONFetcher
+}
+
+func init() {
+	RegisterProvider(GenericHTTPProvider, func(symbol string, client HTTPClient, rawOptions json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+		var options GenericHTTPOptions
+		if err := decodeProviderOptions(GenericHTTPProvider, rawOptions, &options); err != nil {
+			return nil, err
+		}
+
+		return NewGenericHTTPInfoFetcher(symbol, options, client)
+	})
+}
+
+// NewGenericHTTPInfoFetcher creates an instance of GenericHTTPInfoFetcher for `symbol` from an HTTP client instance, validating `options`
+func NewGenericHTTPInfoFetcher(symbol string, options GenericHTTPOptions, client HTTPClient) (*GenericHTTPInfoFetcher, error) {
+	if options.BalanceURL == "" {
+		return nil, fmt.Errorf("%s provider requires balance_url", GenericHTTPProvider)
+	}
+	if options.BalanceResponse == "" {
+		options.BalanceResponse = NumberResponse
+	}
+	if options.RateResponse == "" {
+		options.RateResponse = NumberResponse
+	}
+	if options.Divisor == 0 {
+		options.Divisor = 1
+	}
+	if options.Batching == "" {
+		options.Batching = SingleAddressBatching
+	}
+	if options.Separator == "" {
+		options.Separator = ","
+	}
+
+	for _, responseType := range []string{options.BalanceResponse, options.RateResponse} {
+		if responseType != NumberResponse && responseType != JSONResponse {
+			return nil, fmt.Errorf("unsupported response type %q, expected %q or %q", responseType, NumberResponse, JSONResponse)
+		}
+	}
+	if options.Batching != SingleAddressBatching && options.Batching != JoinedAddressesBatching {
+		return nil, fmt.Errorf("unsupported batching mode %q, expected %q or %q", options.Batching, SingleAddressBatching, JoinedAddressesBatching)
+	}
+
+	return &GenericHTTPInfoFetcher{
+		symbol:        strings.ToLower(symbol),
+		options:       options,
+		numberFetcher: NewWebNumberFetcher(client),
+		jsonFetcher:   NewWebJSONFetcher(client),
+	}, nil
+}
+
+// FetchBalance retrieves the aggregate balances for the provided addresses from the configured balance URL
+func (fetcher *GenericHTTPInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*err = nil
+	*balance = 0.
+
+	batchSize := 1
+	if fetcher.options.Batching == JoinedAddressesBatching {
+		batchSize = fetcher.options.BatchSize
+	}
+
+	*balance, *err = fetchInBatches(addresses, batchSize, func(batch []string) (float64, error) {
+		escapedAddresses := make([]string, len(batch))
+		for index, address := range batch {
+			escapedAddresses[index] = url.QueryEscape(address)
+		}
+
+		requestURL := fetcher.expandURL(fetcher.options.BalanceURL, map[string]string{
+			"{address}":   escapedAddresses[0],
+			"{addresses}": strings.Join(escapedAddresses, fetcher.options.Separator),
+			"{api_key}":   apiKey,
+		})
+		return fetcher.fetchNumber(requestURL, fetcher.options.BalanceResponse, fetcher.options.BalancePath)
+	})
+	*balance /= fetcher.options.Divisor
+}
+
+// FetchExchangeRate retrieves the exchange rate in `targetCurrency` from the configured rate URL
+func (fetcher *GenericHTTPInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate = 0.
+
+	if fetcher.options.RateURL == "" {
+		*err = errorf(UnsupportedCurrencyError, "no rate_url configured to fetch the exchange rate of %s", strings.ToUpper(fetcher.symbol))
+		return
+	}
+
+	requestURL := fetcher.expandURL(fetcher.options.RateURL, map[string]string{"{api_key}": apiKey, "{currency}": targetCurrency})
+	*exchangeRate, *err = fetcher.fetchNumber(requestURL, fetcher.options.RateResponse, fetcher.options.RatePath)
+}
+
+func (fetcher *GenericHTTPInfoFetcher) expandURL(template string, values map[string]string) string {
+	replacements := []string{"{symbol}", fetcher.symbol}
+	for placeholder, value := range values {
+		replacements = append(replacements, placeholder, value)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(template)
+}
+
+func (fetcher *GenericHTTPInfoFetcher) fetchNumber(url string, responseType string, path string) (result float64, err error) {
+	if responseType == NumberResponse {
+		return fetcher.numberFetcher.Fetch(url)
+	}
+
+	var response interface{}
+	if err = fetcher.jsonFetcher.Fetch(url, &response); err != nil {
+		return
+	}
+
+	if result, err = sumJSONPath(response, path); err != nil {
+		err = newError(MalformedResponseError, url, err)
+	}
+
+	return
+}
@@ -0,0 +1,94 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenericHTTPInfoFetcherFetchBalance(t *testing.T) {
+	cases := []struct {
+		name                 string
+		options              fetchers.GenericHTTPOptions
+		addresses            []string
+		responses            map[string]string
+		expectedErrorMessage string
+		expectedBalance      float64
+	}{
+		{"single number responses",
+			fetchers.GenericHTTPOptions{BalanceURL: "https://explorer/{symbol}/balance/{address}?key={api_key}", Divisor: 100},
+			[]string{"a", "b"},
+			map[string]string{"https://explorer/doge/balance/a?key=key": "150", "https://explorer/doge/balance/b?key=key": "50"},
+			"", 2.,
+		},
+		{"joined JSON response with wildcard",
+			fetchers.GenericHTTPOptions{BalanceURL: "https://explorer/balances?a={addresses}", BalanceResponse: fetchers.JSONResponse, BalancePath: "data.*.balance", Batching: fetchers.JoinedAddressesBatching, Separator: "|"},
+			[]string{"a", "b"},
+			map[string]string{"https://explorer/balances?a=a|b": `{"data":[{"balance":"1.5"},{"balance":2}]}`},
+			"", 3.5,
+		},
+		{"JSON response with missing key",
+			fetchers.GenericHTTPOptions{BalanceURL: "https://explorer/balance/{address}", BalanceResponse: fetchers.JSONResponse, BalancePath: "result.balance"},
+			[]string{"a"},
+			map[string]string{"https://explorer/balance/a": `{"result":{"amount":1}}`},
+			`key "balance" not found`, 0.,
+		},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		for url, body := range testCase.responses {
+			clientMock.On("Get", url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil).Once()
+		}
+
+		fetcher, err := fetchers.NewGenericHTTPInfoFetcher("DOGE", testCase.options, clientMock)
+		require.NoError(t, err, testCase.name)
+
+		var balance float64
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchBalance(testCase.addresses, "key", &balance, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+			require.InDelta(t, testCase.expectedBalance, balance, 1e-9, testCase.name)
+		}
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestGenericHTTPInfoFetcherFetchExchangeRate(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "https://prices/doge?vs=usd").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"doge":{"usd":0.25}}`))}, nil).Once()
+
+	options := fetchers.GenericHTTPOptions{BalanceURL: "https://explorer/{address}", RateURL: "https://prices/{symbol}?vs={currency}", RateResponse: fetchers.JSONResponse, RatePath: "doge.usd"}
+	fetcher, err := fetchers.NewGenericHTTPInfoFetcher("DOGE", options, clientMock)
+	require.NoError(t, err)
+
+	var exchangeRate float64
+	var wg sync.WaitGroup
+	wg.Add(1)
+	fetcher.FetchExchangeRate("", "usd", &exchangeRate, &err, &wg)
+	wg.Wait()
+
+	require.NoError(t, err)
+	require.Equal(t, 0.25, exchangeRate)
+	clientMock.AssertExpectations(t)
+}
+
+func TestNewGenericHTTPInfoFetcherValidatesOptions(t *testing.T) {
+	_, err := fetchers.NewGenericHTTPInfoFetcher("DOGE", fetchers.GenericHTTPOptions{}, new(mockHTTPClient))
+	require.EqualError(t, err, "http provider requires balance_url")
+
+	_, err = fetchers.NewGenericHTTPInfoFetcher("DOGE", fetchers.GenericHTTPOptions{BalanceURL: "https://explorer/{address}", BalanceResponse: "xml"}, new(mockHTTPClient))
+	require.EqualError(t, err, `unsupported response type "xml", expected "number" or "json"`)
+}
@@ -1,8 +1,38 @@
 package fetchers
 
-import "net/http"
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+)
 
 // HTTPClient is a facade for http.Client
 type HTTPClient interface {
 	Get(url string) (resp *http.Response, err error)
 }
+
+// fetchBody performs a GET request on `url` and returns the response body, turning failed requests and non-successful status codes into an *Error
+func fetchBody(client HTTPClient, url string) (body []byte, err error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, newError(UnavailableError, url, err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, newError(UnavailableError, url, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		if len(body) > 0 {
+			err = newError(statusErrorKind(resp.StatusCode, string(body)), url, errors.New(string(body)))
+		} else {
+			err = newError(statusErrorKind(resp.StatusCode, string(body)), url, errors.New(resp.Status))
+		}
+		body = nil
+	}
+
+	return
+}
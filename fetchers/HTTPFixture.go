@@ -0,0 +1,115 @@
+package fetchers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// unsafeFileNameCharacters matches the characters replaced in the host part of fixture file names
+var unsafeFileNameCharacters = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// HTTPFixture is an HTTP exchange recorded by RecordingHTTPClient and served by ReplayingHTTPClient.
+// Each fixture is stored as a JSON file in a fixture directory, named after the request it answers
+type HTTPFixture struct {
+	Method string `json:"method"`
+	// URL is the requested URL, with credentials redacted so that fixtures can be shared
+	URL         string `json:"url"`
+	RequestBody string `json:"request_body,omitempty"`
+
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// httpFixtureFileName returns the name of the file holding the fixture answering a request.
+// Requests are identified by their method, redacted URL and body, so that a session recorded with an API key can be replayed with another one
+func httpFixtureFileName(method string, rawURL string, requestBody string) string {
+	redactedURL := redactURL(rawURL)
+	hash := sha256.Sum256([]byte(method + " " + redactedURL + "\n" + requestBody))
+
+	host := "unknown"
+	if parsedURL, err := url.Parse(redactedURL); err == nil && parsedURL.Host != "" {
+		host = unsafeFileNameCharacters.ReplaceAllString(parsedURL.Host, "_")
+	}
+
+	return fmt.Sprintf("%s-%s-%s.json", strings.ToLower(method), host, hex.EncodeToString(hash[:8]))
+}
+
+// readRequestBody reads the body of `req` and restores it, so that the request can still be sent
+func readRequestBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+
+	return string(body), nil
+}
+
+// loadHTTPFixture reads the fixture stored in `fileName` within `directory`
+func loadHTTPFixture(directory string, fileName string) (*HTTPFixture, error) {
+	data, err := ioutil.ReadFile(filepath.Join(directory, fileName))
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &HTTPFixture{}
+	if err = json.Unmarshal(data, fixture); err != nil {
+		return nil, fmt.Errorf("invalid HTTP fixture %s: %s", fileName, err)
+	}
+
+	return fixture, nil
+}
+
+// save writes the fixture to `fileName` within `directory`, creating the directory if needed
+func (fixture *HTTPFixture) save(directory string, fileName string) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+
+	// Keep URLs readable rather than escaping their ampersands
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(fixture); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(directory, fileName), data.Bytes(), 0644)
+}
+
+// response builds the HTTP response replaying the fixture for `req`
+func (fixture *HTTPFixture) response(req *http.Request) *http.Response {
+	header := fixture.Header
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fixture.StatusCode, http.StatusText(fixture.StatusCode)),
+		StatusCode:    fixture.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(fixture.Body)),
+		ContentLength: int64(len(fixture.Body)),
+		Request:       req,
+	}
+}
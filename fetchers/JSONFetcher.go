@@ -3,7 +3,7 @@ package fetchers
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"strings"
 )
 
 // JSONFetcher defines an interface for fetching JSON responses from web APIs
@@ -11,6 +11,30 @@ type JSONFetcher interface {
 	Fetch(url string, response interface{}) error
 }
 
+// webJSONFetcher implements the JSONFetcher interface for an HTTPClient
+type webJSONFetcher struct {
+	client HTTPClient
+}
+
+// NewWebJSONFetcher returns a JSONFetcher implementation that works on an HTTPClient
+func NewWebJSONFetcher(client HTTPClient) JSONFetcher {
+	return &webJSONFetcher{client}
+}
+
+// Fetch calls a web API and decodes the JSON response
+func (fetcher *webJSONFetcher) Fetch(url string, response interface{}) (err error) {
+	body, err := fetchBody(fetcher.client, url)
+	if err != nil {
+		return
+	}
+
+	if err = json.Unmarshal(body, response); err != nil {
+		err = newError(MalformedResponseError, url, err)
+	}
+
+	return
+}
+
 // etherscanJSONFetcher implements the JSONFetcher interface for an HTTPClient to parse an etherscan.io response
 type etherscanJSONFetcher struct {
 	client HTTPClient
@@ -21,32 +45,53 @@ func NewEtherscanJSONFetcher(client HTTPClient) JSONFetcher {
 	return &etherscanJSONFetcher{client}
 }
 
-// Fetch calls a web API and decodes the JSON response
+// Fetch calls a web API and decodes the JSON response, turning responses with an error status into an *Error classified by their message
 func (fetcher *etherscanJSONFetcher) Fetch(url string, response interface{}) (err error) {
-	resp, err := fetcher.client.Get(url)
+	body, err := fetchBody(fetcher.client, url)
 	if err != nil {
 		return
 	}
 
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return
+	var header struct {
+		Status  string          `json:"status"`
+		Message string          `json:"message"`
+		Result  json.RawMessage `json:"result"`
 	}
-
-	var untypedResponse map[string]interface{}
-	err = json.Unmarshal([]byte(body), &untypedResponse)
-	if err != nil {
-		return
+	if err = json.Unmarshal(body, &header); err != nil {
+		return newError(MalformedResponseError, url, err)
 	}
 
-	if untypedResponse["status"].(string) != "1" {
-		err = errors.New(untypedResponse["message"].(string))
-		return
+	// Etherscan reports empty lists, such as the transactions of an unused address, with an error status
+	var list []json.RawMessage
+	isEmptyList := json.Unmarshal(header.Result, &list) == nil && list != nil && len(list) == 0
+	if header.Status != "1" && !isEmptyList {
+		// The message of errors is a generic "NOTOK", while the result describes the error (e.g. "Max rate limit reached")
+		description := header.Message
+		var result string
+		if json.Unmarshal(header.Result, &result) == nil && result != "" {
+			description = result
+		}
+		return newError(etherscanErrorKind(description), url, errors.New(description))
 	}
 
-	json.Unmarshal([]byte(body), response)
+	if err = json.Unmarshal(body, response); err != nil {
+		err = newError(MalformedResponseError, url, err)
+	}
 
 	return
 }
+
+// etherscanErrorKind classifies an error reported by Etherscan from its description
+func etherscanErrorKind(description string) ErrorKind {
+	description = strings.ToLower(description)
+	switch {
+	case strings.Contains(description, "rate limit"):
+		return RateLimitedError
+	case strings.Contains(description, "api key"):
+		return UnauthorizedError
+	case strings.Contains(description, "address"):
+		return InvalidAddressError
+	default:
+		return UnknownError
+	}
+}
@@ -37,7 +37,9 @@ func TestEtherscanJSONFetcherFetch(t *testing.T) {
 	}{
 		{"https://api.etherscan.io/api?module=account&action=balancemulti&address=0,1&tag=latest", `{"status":"1","message":"OK","result":[{"account":"0","balance":"190.123"},{"account":"1","balance":"100"}]}`, "200", 200, "", "", "1", []string{"190.123", "100"}},
 		{"https://api.etherscan.io/api?module=account&action=balancemulti&address=1&tag=latest", `{"status":"1","message":"OK","result":[{"account":"1","balance":"100"}]}`, "200", 200, "", "", "1", []string{"100"}},
-		{"https://api.etherscan.io/api?module=account&action=balancemulti", `{"status":"0","message":"NOTOK","result":"Error!"}`, "200", 200, "", "NOTOK", "", nil},
+		{"https://api.etherscan.io/api?module=account&action=balancemulti", `{"status":"0","message":"NOTOK","result":"Error!"}`, "200", 200, "", "Error!", "", nil},
+		{"https://api.etherscan.io/api?module=proxy&action=eth_blockNumber", `{"status":"0","message":"Query Timeout occured","result":null}`, "200", 200, "", "Query Timeout occured", "", nil},
+		{"https://api.etherscan.io/api?module=account&action=txlist&address=0", `{"status":"0","message":"No transactions found","result":[]}`, "200", 200, "", "", "0", nil},
 		{"https://somesite", `Hello!`, "200", 200, "", "invalid character 'H' looking for beginning of value", "", nil},
 		{"https://api.etherscan.io/api2", `Server Error in '/' Application.`, "404", 404, "Server Error in '/' Application.", "Server Error in '/' Application.", "", nil},
 	}
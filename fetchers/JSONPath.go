@@ -0,0 +1,95 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sumJSONPath evaluates a dot-separated path expression (e.g. `data.balances.0.amount`) against a decoded JSON value
+// and returns the sum of the numbers it points to. A `*` segment matches every element of an array or object.
+// Numbers encoded as JSON strings are also accepted.
+func sumJSONPath(value interface{}, path string) (sum float64, err error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+
+	var segments []string
+	if path != "" {
+		segments = strings.Split(path, ".")
+	}
+
+	matches, err := matchJSONPath(value, segments)
+	if err != nil {
+		return
+	}
+	if len(matches) == 0 {
+		err = fmt.Errorf("path %q did not match any value", path)
+		return
+	}
+
+	for _, match := range matches {
+		var number float64
+		switch typedMatch := match.(type) {
+		case float64:
+			number = typedMatch
+		case json.Number:
+			number, err = typedMatch.Float64()
+		case string:
+			number, err = strconv.ParseFloat(typedMatch, 64)
+		default:
+			err = fmt.Errorf("path %q points to a non-numeric value", path)
+		}
+		if err != nil {
+			return
+		}
+
+		sum += number
+	}
+
+	return
+}
+
+func matchJSONPath(value interface{}, segments []string) ([]interface{}, error) {
+	if len(segments) == 0 {
+		return []interface{}{value}, nil
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	var children []interface{}
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			for _, child := range typedValue {
+				children = append(children, child)
+			}
+		} else if child, ok := typedValue[segment]; ok {
+			children = append(children, child)
+		} else {
+			return nil, fmt.Errorf("key %q not found", segment)
+		}
+	case []interface{}:
+		if segment == "*" {
+			children = typedValue
+		} else {
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(typedValue) {
+				return nil, fmt.Errorf("invalid array index %q", segment)
+			}
+			children = append(children, typedValue[index])
+		}
+	default:
+		return nil, fmt.Errorf("cannot look up %q in a non-container value", segment)
+	}
+
+	var matches []interface{}
+	for _, child := range children {
+		childMatches, err := matchJSONPath(child, rest)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, childMatches...)
+	}
+
+	return matches, nil
+}
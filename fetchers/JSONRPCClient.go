@@ -0,0 +1,224 @@
+package fetchers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// HTTPDoer is implemented by HTTP clients able to send arbitrary requests, such as http.Client. It is required by the node backends, which POST JSON-RPC requests
+type HTTPDoer interface {
+	Do(req *http.Request) (resp *http.Response, err error)
+}
+
+// JSONRPCOptions holds the connection options shared by the providers talking to a node over JSON-RPC
+type JSONRPCOptions struct {
+	// URL is the JSON-RPC endpoint of the node, e.g. http://127.0.0.1:8332
+	URL string `json:"url,omitempty"`
+	// User and Password are the credentials used for HTTP basic authentication. Optional
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+	// CookieFile is the path of the `.cookie` file written by the node, holding `user:password` credentials. It is read on every request,
+	// since the node rotates it on restart. Takes precedence over User and Password
+	CookieFile string `json:"cookie_file,omitempty"`
+}
+
+// jsonRPCClient sends JSON-RPC requests to a node
+type jsonRPCClient struct {
+	provider string
+	client   HTTPDoer
+	options  JSONRPCOptions
+}
+
+// jsonRPCRequest holds a single JSON-RPC request
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// jsonRPCError holds the error member of a JSON-RPC response
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (err *jsonRPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", err.Code, err.Message)
+}
+
+// jsonRPCResponse holds a single JSON-RPC response
+type jsonRPCResponse struct {
+	ID     int             `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *jsonRPCError   `json:"error"`
+}
+
+// jsonRPCCall describes a call of a JSON-RPC batch, whose result is decoded into Result
+type jsonRPCCall struct {
+	Method string
+	Params []interface{}
+	Result interface{}
+}
+
+// newJSONRPCClient creates a jsonRPCClient for `provider` from an HTTP client instance, which must implement HTTPDoer
+func newJSONRPCClient(provider string, client HTTPClient, options JSONRPCOptions) (*jsonRPCClient, error) {
+	if options.URL == "" {
+		return nil, fmt.Errorf("%s requires a url option", provider)
+	}
+
+	doer, ok := client.(HTTPDoer)
+	if !ok {
+		return nil, fmt.Errorf("%s requires an HTTP client able to send POST requests, not %T", provider, client)
+	}
+
+	return &jsonRPCClient{provider, doer, options}, nil
+}
+
+// call sends a single JSON-RPC request and decodes its result into `result`
+func (client *jsonRPCClient) call(method string, params []interface{}, result interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	logger().Debug("sending JSON-RPC call", "provider", client.provider, "url", logURL(client.options.URL), "method", method)
+
+	var response jsonRPCResponse
+	if err := client.post(&jsonRPCRequest{"2.0", 1, method, params}, &response); err != nil {
+		return err
+	}
+
+	return response.decode(result)
+}
+
+// batch sends the calls in a single JSON-RPC batch request and decodes each result into the Result of its call.
+// The first error reported for any of the calls is returned
+func (client *jsonRPCClient) batch(calls []*jsonRPCCall) error {
+	if len(calls) == 0 {
+		return nil
+	}
+
+	requests := make([]*jsonRPCRequest, len(calls))
+	for index, call := range calls {
+		params := call.Params
+		if params == nil {
+			params = []interface{}{}
+		}
+		requests[index] = &jsonRPCRequest{"2.0", index, call.Method, params}
+	}
+
+	logger().Debug("sending JSON-RPC batch", "provider", client.provider, "url", logURL(client.options.URL), "calls", len(calls), "method", calls[0].Method)
+
+	var responses []*jsonRPCResponse
+	if err := client.post(requests, &responses); err != nil {
+		return err
+	}
+
+	// Responses to a batch may be returned in any order, so they are matched to their call by id
+	answered := make([]bool, len(calls))
+	for _, response := range responses {
+		if response.ID < 0 || response.ID >= len(calls) || answered[response.ID] {
+			return errorf(MalformedResponseError, "unexpected json-rpc response id %d", response.ID)
+		}
+		answered[response.ID] = true
+
+		if err := response.decode(calls[response.ID].Result); err != nil {
+			return err
+		}
+	}
+	for index, ok := range answered {
+		if !ok {
+			return errorf(MalformedResponseError, "missing json-rpc response to %s", calls[index].Method)
+		}
+	}
+
+	return nil
+}
+
+// decode unmarshals the result of the response into `result`, or returns the error reported by the node
+func (response *jsonRPCResponse) decode(result interface{}) error {
+	if response.Error != nil {
+		return response.Error
+	}
+	if result == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(response.Result, result); err != nil {
+		return newError(MalformedResponseError, "", err)
+	}
+
+	return nil
+}
+
+// post sends `payload` to the node and decodes the JSON response into `response`
+func (client *jsonRPCClient) post(payload interface{}, response interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, client.options.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	user, password, err := client.credentials()
+	if err != nil {
+		return err
+	}
+	if user != "" || password != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return newError(UnavailableError, client.options.URL, err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return newError(UnavailableError, client.options.URL, err)
+	}
+
+	// Nodes such as Bitcoin Core report RPC errors with a non-successful status code, but still include a JSON-RPC error in the body
+	if err = json.Unmarshal(body, response); err != nil {
+		switch {
+		case resp.StatusCode < 300:
+			return newError(MalformedResponseError, client.options.URL, err)
+		case len(body) > 0:
+			return newError(statusErrorKind(resp.StatusCode, string(body)), client.options.URL, errors.New(strings.TrimSpace(string(body))))
+		default:
+			return newError(statusErrorKind(resp.StatusCode, string(body)), client.options.URL, errors.New(resp.Status))
+		}
+	}
+
+	return nil
+}
+
+// credentials returns the basic authentication credentials, read from the cookie file if one is configured
+func (client *jsonRPCClient) credentials() (user, password string, err error) {
+	if client.options.CookieFile == "" {
+		return client.options.User, client.options.Password, nil
+	}
+
+	cookie, err := ioutil.ReadFile(client.options.CookieFile)
+	if err != nil {
+		return "", "", newError(UnauthorizedError, "", err)
+	}
+
+	separator := strings.IndexByte(string(cookie), ':')
+	if separator < 0 {
+		return "", "", errorf(UnauthorizedError, "invalid cookie file %s", client.options.CookieFile)
+	}
+
+	return string(cookie[:separator]), strings.TrimSpace(string(cookie[separator+1:])), nil
+}
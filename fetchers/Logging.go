@@ -0,0 +1,78 @@
+package fetchers
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// addressPlaceholder replaces the redacted addresses in logged URLs
+const addressPlaceholder = "REDACTED_ADDRESS"
+
+// currentLogger holds the logger of the fetchers package. It is swapped atomically, so that SetLogger can be called while fetchers are running
+var currentLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	currentLogger.Store(slog.New(discardHandler{}))
+}
+
+// logger returns the logger of the fetchers package. It discards everything until SetLogger is called
+func logger() *slog.Logger {
+	return currentLogger.Load()
+}
+
+// addressRedactor hides the addresses registered with RedactAddressesInLogs from logged URLs. Nil if addresses are logged
+var addressRedactor struct {
+	sync.RWMutex
+	replacer *strings.Replacer
+}
+
+// SetLogger sets the logger used to report the requests sent by fetchers, their latency and retries
+func SetLogger(newLogger *slog.Logger) {
+	currentLogger.Store(newLogger)
+}
+
+// RedactAddressesInLogs hides `addresses` from the URLs logged from now on. API keys and passwords are always hidden
+func RedactAddressesInLogs(addresses []string) {
+	replacements := make([]string, 0, 2*len(addresses))
+	for _, address := range addresses {
+		if address != "" {
+			replacements = append(replacements, address, addressPlaceholder)
+		}
+	}
+
+	addressRedactor.Lock()
+	defer addressRedactor.Unlock()
+
+	addressRedactor.replacer = nil
+	if len(replacements) > 0 {
+		addressRedactor.replacer = strings.NewReplacer(replacements...)
+	}
+}
+
+// logURL returns `rawURL` as it should appear in logs, with credentials and, if requested, addresses hidden
+func logURL(rawURL string) string {
+	return redactAddresses(redactURL(rawURL))
+}
+
+// redactAddresses hides the addresses registered with RedactAddressesInLogs from `text`
+func redactAddresses(text string) string {
+	addressRedactor.RLock()
+	defer addressRedactor.RUnlock()
+
+	if addressRedactor.replacer != nil {
+		return addressRedactor.replacer.Replace(text)
+	}
+
+	return text
+}
+
+// discardHandler is a slog.Handler which drops all records
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool   { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error  { return nil }
+func (handler discardHandler) WithAttrs([]slog.Attr) slog.Handler { return handler }
+func (handler discardHandler) WithGroup(string) slog.Handler      { return handler }
@@ -0,0 +1,107 @@
+package fetchers
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"time"
+)
+
+// maxRetryAfter caps the delay requested by a provider through a Retry-After header
+const maxRetryAfter = 30 * time.Second
+
+// LoggingHTTPClient is an HTTPClient which logs every request it sends with its status and latency,
+// and retries requests which were rate limited or failed transiently
+type LoggingHTTPClient struct {
+	client     HTTPDoer
+	maxRetries int
+	retryDelay time.Duration
+}
+
+// NewLoggingHTTPClient creates an instance of LoggingHTTPClient sending requests with `client`. Failed requests are retried up to `maxRetries` times,
+// after `retryDelay` doubled on each attempt unless the provider specifies a delay
+func NewLoggingHTTPClient(client HTTPDoer, maxRetries int, retryDelay time.Duration) *LoggingHTTPClient {
+	return &LoggingHTTPClient{client: client, maxRetries: maxRetries, retryDelay: retryDelay}
+}
+
+// Get performs a GET request on `url`
+func (client *LoggingHTTPClient) Get(url string) (resp *http.Response, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+}
+
+// Do sends `req`, retrying it while it fails transiently
+func (client *LoggingHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	url := logURL(req.URL.String())
+
+	for attempt := 1; ; attempt++ {
+		started := time.Now()
+		resp, err = client.client.Do(req)
+		latency := time.Since(started)
+
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			// Keep API keys out of the error messages reported to the user
+			urlErr.URL = redactURL(urlErr.URL)
+		}
+
+		switch {
+		case err != nil:
+			logger().Warn("request failed", "method", req.Method, "url", url, "attempt", attempt, "latency", latency, "error", redactAddresses(err.Error()))
+		case resp.StatusCode >= 400:
+			logger().Warn("request rejected", "method", req.Method, "url", url, "attempt", attempt, "status", resp.StatusCode, "latency", latency)
+		default:
+			logger().Debug("request completed", "method", req.Method, "url", url, "attempt", attempt, "status", resp.StatusCode, "latency", latency)
+		}
+
+		delay, retryable := client.retryDelayAfter(attempt, resp, err)
+		if !retryable || attempt > client.maxRetries || (req.Body != nil && req.GetBody == nil) || req.Context().Err() != nil {
+			return
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+
+		logger().Info("retrying request", "method", req.Method, "url", url, "attempt", attempt+1, "delay", delay)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryDelayAfter returns whether the outcome of an attempt is worth retrying and after how long
+func (client *LoggingHTTPClient) retryDelayAfter(attempt int, resp *http.Response, err error) (delay time.Duration, retryable bool) {
+	delay = client.retryDelay << uint(attempt-1)
+
+	switch {
+	case err != nil:
+		return delay, true
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusBadGateway,
+		resp.StatusCode == http.StatusServiceUnavailable, resp.StatusCode == http.StatusGatewayTimeout:
+		if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds >= 0 {
+			delay = time.Duration(seconds) * time.Second
+			if delay > maxRetryAfter {
+				delay = maxRetryAfter
+			}
+		}
+		return delay, true
+	default:
+		return 0, false
+	}
+}
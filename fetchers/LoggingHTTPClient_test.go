@@ -0,0 +1,172 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs routes the fetchers log to a buffer for the duration of the test
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var logs bytes.Buffer
+	fetchers.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() {
+		fetchers.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		fetchers.RedactAddressesInLogs(nil)
+	})
+
+	return &logs
+}
+
+// newStatusSequenceServer creates a server answering with each of `statusCodes` in turn, then with 200, echoing the request body
+func newStatusSequenceServer(t *testing.T, statusCodes []int, requests *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		index := int(atomic.AddInt32(requests, 1)) - 1
+		body, _ := ioutil.ReadAll(r.Body)
+		if index < len(statusCodes) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statusCodes[index])
+			return
+		}
+		w.Write(append([]byte("ok:"), body...))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestLoggingHTTPClientRetries(t *testing.T) {
+	cases := []struct {
+		name               string
+		statusCodes        []int
+		expectedRequests   int32
+		expectedStatusCode int
+	}{
+		{"success", nil, 1, 200},
+		{"rate limited then success", []int{429}, 2, 200},
+		{"unavailable then success", []int{503, 502}, 3, 200},
+		{"retries exhausted", []int{503, 503, 503, 503}, 3, 503},
+		{"client errors are not retried", []int{404}, 1, 404},
+	}
+
+	for _, testCase := range cases {
+		var requests int32
+		server := newStatusSequenceServer(t, testCase.statusCodes, &requests)
+
+		client := fetchers.NewLoggingHTTPClient(http.DefaultClient, 2, time.Millisecond)
+		resp, err := client.Get(server.URL + "/balance")
+
+		require.NoError(t, err, testCase.name)
+		resp.Body.Close()
+		require.Equal(t, testCase.expectedStatusCode, resp.StatusCode, testCase.name)
+		require.Equal(t, testCase.expectedRequests, atomic.LoadInt32(&requests), testCase.name)
+	}
+}
+
+func TestLoggingHTTPClientReplaysBodyOnRetry(t *testing.T) {
+	var requests int32
+	server := newStatusSequenceServer(t, []int{429}, &requests)
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"method":"getblockcount"}`))
+	require.NoError(t, err)
+
+	resp, err := fetchers.NewLoggingHTTPClient(http.DefaultClient, 2, time.Millisecond).Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, `ok:{"method":"getblockcount"}`, string(body))
+	require.Equal(t, int32(2), atomic.LoadInt32(&requests))
+}
+
+func TestLoggingHTTPClientRedactsLogs(t *testing.T) {
+	logs := captureLogs(t)
+	fetchers.RedactAddressesInLogs([]string{"1BoatSLRHtKNngkdXEeobR76b53LETtpyT"})
+
+	var requests int32
+	server := newStatusSequenceServer(t, []int{429}, &requests)
+
+	client := fetchers.NewLoggingHTTPClient(http.DefaultClient, 1, time.Millisecond)
+	resp, err := client.Get(server.URL + "/balance?active=1BoatSLRHtKNngkdXEeobR76b53LETtpyT&apikey=secret123")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	output := logs.String()
+	require.Contains(t, output, "request rejected")
+	require.Contains(t, output, "status=429")
+	require.Contains(t, output, "retrying request")
+	require.Contains(t, output, "request completed")
+	require.Contains(t, output, "latency=")
+	require.NotContains(t, output, "secret123")
+	require.NotContains(t, output, "1BoatSLRHtKNngkdXEeobR76b53LETtpyT")
+}
+
+func TestLoggingHTTPClientRedactsErrors(t *testing.T) {
+	captureLogs(t)
+
+	client := fetchers.NewLoggingHTTPClient(http.DefaultClient, 0, time.Millisecond)
+	_, err := client.Get("http://127.0.0.1:1/balance?key=secret123")
+
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "secret123")
+}
+
+func TestLoggingHTTPClientStopsRetryingOnceCanceled(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	client := fetchers.NewContextHTTPClient(ctx, fetchers.NewLoggingHTTPClient(&http.Client{}, 3, time.Hour))
+
+	started := time.Now()
+	_, err := client.Get(server.URL)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), "%v", err)
+	require.Less(t, int64(time.Since(started)), int64(time.Second))
+	require.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestLoggingHTTPClientLoggerCanBeSetWhileRequesting(t *testing.T) {
+	var requests int32
+	server := newStatusSequenceServer(t, nil, &requests)
+	client := fetchers.NewLoggingHTTPClient(http.DefaultClient, 0, 0)
+	t.Cleanup(func() { fetchers.SetLogger(slog.New(slog.NewTextHandler(io.Discard, nil))) })
+
+	var wg sync.WaitGroup
+	for index := 0; index < 4; index++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for request := 0; request < 10; request++ {
+				resp, err := client.Get(server.URL)
+				require.NoError(t, err)
+				resp.Body.Close()
+			}
+		}()
+	}
+	// Run with -race: the logger is swapped while the requests above are being logged
+	for index := 0; index < 10; index++ {
+		fetchers.SetLogger(slog.New(slog.NewTextHandler(io.Discard, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(40), atomic.LoadInt32(&requests))
+}
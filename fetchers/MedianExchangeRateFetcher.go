@@ -0,0 +1,170 @@
+package fetchers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxExchangeRateDeviation is the default relative deviation from the median beyond which a quote is discarded as an outlier
+const DefaultMaxExchangeRateDeviation = 0.1
+
+// minQuotesForOutliers is the number of quotes needed to tell an outlier apart: with two, the median lies halfway between them,
+// so that neither is closer to it and both would be discarded. Two quotes are averaged instead, and their spread reported
+const minQuotesForOutliers = 3
+
+// ExchangeRateSource is a named source of exchange rates
+type ExchangeRateSource struct {
+	Name    string
+	Fetcher CryptoCurrencyExchangeRateFetcher
+}
+
+// ExchangeRateQuote records the exchange rate quoted by a single source
+type ExchangeRateQuote struct {
+	Source  string
+	Rate    float64
+	Err     error
+	Outlier bool
+}
+
+// ExchangeRateAuditor is implemented by exchange rate fetchers that can report the quotes the last exchange rate was derived from
+type ExchangeRateAuditor interface {
+	// ExchangeRateQuotes returns the individual quotes and their spread, relative to the resulting exchange rate
+	ExchangeRateQuotes() (quotes []*ExchangeRateQuote, spread float64)
+}
+
+// MedianExchangeRateFetcher queries several exchange rate sources concurrently and returns the median of the quotes, discarding outliers
+// when at least three sources succeed
+type MedianExchangeRateFetcher struct {
+	sources      []*ExchangeRateSource
+	maxDeviation float64
+
+	mutex  sync.Mutex
+	quotes []*ExchangeRateQuote
+	spread float64
+}
+
+// NewMedianExchangeRateFetcher creates an instance of MedianExchangeRateFetcher which discards quotes deviating from the median by more than `maxDeviation` (e.g. 0.1 for 10%)
+func NewMedianExchangeRateFetcher(sources []*ExchangeRateSource, maxDeviation float64) *MedianExchangeRateFetcher {
+	if maxDeviation <= 0 {
+		maxDeviation = DefaultMaxExchangeRateDeviation
+	}
+
+	return &MedianExchangeRateFetcher{sources: sources, maxDeviation: maxDeviation}
+}
+
+// FetchExchangeRate retrieves the exchange rate in `targetCurrency` from all sources and returns the median of the quotes which are not outliers
+func (fetcher *MedianExchangeRateFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate, *err = fetcher.aggregate(fetcher.fetchQuotes(func(source *ExchangeRateSource, quote *ExchangeRateQuote, quoteFetched *sync.WaitGroup) {
+		source.Fetcher.FetchExchangeRate(apiKey, targetCurrency, &quote.Rate, &quote.Err, quoteFetched)
+	}))
+}
+
+// FetchExchangeRateAt retrieves the daily exchange rate in `targetCurrency` on the date of `at` from all sources supporting historical exchange rates,
+// and returns the median of the quotes which are not outliers
+func (fetcher *MedianExchangeRateFetcher) FetchExchangeRateAt(apiKey string, targetCurrency string, at time.Time, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate, *err = fetcher.aggregate(fetcher.fetchQuotes(func(source *ExchangeRateSource, quote *ExchangeRateQuote, quoteFetched *sync.WaitGroup) {
+		historicalFetcher, ok := source.Fetcher.(CryptoCurrencyHistoricalExchangeRateFetcher)
+		if !ok {
+			quote.Err = fmt.Errorf("historical exchange rates are not supported by %s", source.Name)
+			quoteFetched.Done()
+			return
+		}
+
+		historicalFetcher.FetchExchangeRateAt(apiKey, targetCurrency, at, &quote.Rate, &quote.Err, quoteFetched)
+	}))
+}
+
+// fetchQuotes concurrently calls `fetch` for every source and returns the resulting quotes
+func (fetcher *MedianExchangeRateFetcher) fetchQuotes(fetch func(source *ExchangeRateSource, quote *ExchangeRateQuote, quoteFetched *sync.WaitGroup)) []*ExchangeRateQuote {
+	quotes := make([]*ExchangeRateQuote, len(fetcher.sources))
+	quotesFetched := sync.WaitGroup{}
+	quotesFetched.Add(len(fetcher.sources))
+	for index, source := range fetcher.sources {
+		quotes[index] = &ExchangeRateQuote{Source: source.Name}
+		go fetch(source, quotes[index], &quotesFetched)
+	}
+	quotesFetched.Wait()
+
+	return quotes
+}
+
+// ExchangeRateQuotes returns the quotes the last exchange rate was derived from, and their spread ((max-min)/median)
+func (fetcher *MedianExchangeRateFetcher) ExchangeRateQuotes() ([]*ExchangeRateQuote, float64) {
+	fetcher.mutex.Lock()
+	defer fetcher.mutex.Unlock()
+
+	return fetcher.quotes, fetcher.spread
+}
+
+func (fetcher *MedianExchangeRateFetcher) aggregate(quotes []*ExchangeRateQuote) (exchangeRate float64, err error) {
+	var rates []float64
+	var errorMessages []string
+	for _, quote := range quotes {
+		if quote.Err == nil {
+			rates = append(rates, quote.Rate)
+		} else {
+			errorMessages = append(errorMessages, fmt.Sprintf("%s: %s", quote.Source, quote.Err))
+			logger().Warn("exchange rate source failed", "source", quote.Source, "kind", ErrorKindOf(quote.Err).String(), "error", quote.Err)
+		}
+	}
+
+	spread := 0.
+	defer func() {
+		fetcher.mutex.Lock()
+		fetcher.quotes, fetcher.spread = quotes, spread
+		fetcher.mutex.Unlock()
+	}()
+
+	if len(rates) == 0 {
+		err = errorf(UnavailableError, "no exchange rate source succeeded (%s)", strings.Join(errorMessages, "; "))
+		return
+	}
+
+	overallMedian := median(rates)
+	if overallMedian > 0 {
+		sort.Float64s(rates)
+		spread = (rates[len(rates)-1] - rates[0]) / overallMedian
+	}
+
+	var inliers []float64
+	for _, quote := range quotes {
+		if quote.Err != nil {
+			continue
+		}
+		if len(rates) >= minQuotesForOutliers && overallMedian > 0 && math.Abs(quote.Rate-overallMedian)/overallMedian > fetcher.maxDeviation {
+			quote.Outlier = true
+			logger().Warn("exchange rate quote discarded as an outlier", "source", quote.Source, "rate", quote.Rate, "median", overallMedian)
+		} else {
+			inliers = append(inliers, quote.Rate)
+		}
+	}
+
+	if len(inliers) == 0 {
+		err = fmt.Errorf("exchange rate sources disagree by more than %.0f%%", fetcher.maxDeviation*100)
+		return
+	}
+
+	exchangeRate = median(inliers)
+
+	return
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+
+	return sorted[middle]
+}
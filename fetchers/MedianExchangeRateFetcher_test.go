@@ -0,0 +1,89 @@
+package fetchers_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+type staticExchangeRateFetcher struct {
+	exchangeRate float64
+	err          error
+}
+
+func (fetcher *staticExchangeRateFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate, *err = fetcher.exchangeRate, fetcher.err
+	done.Done()
+}
+
+func TestMedianExchangeRateFetcherFetchExchangeRate(t *testing.T) {
+	cases := []struct {
+		name                 string
+		quotes               []*staticExchangeRateFetcher
+		expectedErrorMessage string
+		expectedExchangeRate float64
+		expectedOutliers     []bool
+		expectedSpread       float64
+	}{
+		{"median of three",
+			[]*staticExchangeRateFetcher{{exchangeRate: 740.}, {exchangeRate: 750.}, {exchangeRate: 745.}},
+			"", 745., []bool{false, false, false}, 10. / 745.,
+		},
+		{"outlier is discarded",
+			[]*staticExchangeRateFetcher{{exchangeRate: 740.}, {exchangeRate: 7420.}, {exchangeRate: 750.}, {exchangeRate: 745.}},
+			"", 745., []bool{false, true, false, false}, 6680. / 747.5,
+		},
+		{"failed source is ignored",
+			[]*staticExchangeRateFetcher{{exchangeRate: 740.}, {err: errors.New("unavailable")}, {exchangeRate: 750.}},
+			"", 745., []bool{false, false, false}, 10. / 745.,
+		},
+		{"all sources fail",
+			[]*staticExchangeRateFetcher{{err: errors.New("unavailable")}, {err: errors.New("rate limited")}},
+			"no exchange rate source succeeded (source0: unavailable; source1: rate limited)", 0., []bool{false, false}, 0.,
+		},
+		{"two sources disagree",
+			[]*staticExchangeRateFetcher{{exchangeRate: 100.}, {exchangeRate: 1000.}},
+			"", 550., []bool{false, false}, 900. / 550.,
+		},
+		{"two of three sources succeed and disagree",
+			[]*staticExchangeRateFetcher{{exchangeRate: 700.}, {err: errors.New("unavailable")}, {exchangeRate: 800.}},
+			"", 750., []bool{false, false, false}, 100. / 750.,
+		},
+		{"four sources split in two",
+			[]*staticExchangeRateFetcher{{exchangeRate: 100.}, {exchangeRate: 1000.}, {exchangeRate: 100.}, {exchangeRate: 1000.}},
+			"exchange rate sources disagree by more than 10%", 0., []bool{true, true, true, true}, 900. / 550.,
+		},
+	}
+
+	for _, testCase := range cases {
+		sources := make([]*fetchers.ExchangeRateSource, len(testCase.quotes))
+		for index, quote := range testCase.quotes {
+			sources[index] = &fetchers.ExchangeRateSource{Name: "source" + string(rune('0'+index)), Fetcher: quote}
+		}
+		fetcher := fetchers.NewMedianExchangeRateFetcher(sources, 0)
+
+		var exchangeRate float64
+		var err error
+		var wg sync.WaitGroup
+		wg.Add(1)
+		fetcher.FetchExchangeRate("", "usd", &exchangeRate, &err, &wg)
+		wg.Wait()
+
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+		}
+		require.Equal(t, testCase.expectedExchangeRate, exchangeRate, testCase.name)
+
+		quotes, spread := fetcher.ExchangeRateQuotes()
+		require.Len(t, quotes, len(testCase.quotes), testCase.name)
+		for index, quote := range quotes {
+			require.Equal(t, testCase.expectedOutliers[index], quote.Outlier, "%s: quote #%d", testCase.name, index)
+		}
+		require.InDelta(t, testCase.expectedSpread, spread, 1e-9, testCase.name)
+	}
+}
@@ -1,8 +1,6 @@
 package fetchers
 
 import (
-	"errors"
-	"io/ioutil"
 	"strconv"
 )
 
@@ -21,32 +19,16 @@ func NewWebNumberFetcher(client HTTPClient) NumberFetcher {
 	return &webNumberFetcher{client}
 }
 
-// Fetch calls a web API and decodes the JSON response
+// Fetch calls a web API and parses the numeric response
 func (fetcher *webNumberFetcher) Fetch(url string) (result float64, err error) {
-	resp, err := fetcher.client.Get(url)
+	body, err := fetchBody(fetcher.client, url)
 	if err != nil {
 		return
 	}
 
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return
-	}
-
-	bodyString := string(body)
-	if resp.StatusCode >= 300 {
-		if len(bodyString) > 0 {
-			err = errors.New(bodyString)
-		} else {
-			err = errors.New(resp.Status)
-		}
-
-		return
+	if result, err = strconv.ParseFloat(string(body), 64); err != nil {
+		err = newError(MalformedResponseError, url, err)
 	}
 
-	result, err = strconv.ParseFloat(bodyString, 64)
-
 	return
 }
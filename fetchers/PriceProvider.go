@@ -0,0 +1,182 @@
+package fetchers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PriceProvider defines the interface for fetching the prices of several crypto-currencies in a single call
+type PriceProvider interface {
+	// PriceID returns the identifier used by the provider for the crypto-currency with ticker symbol `symbol`
+	PriceID(symbol string) string
+	// FetchPrices retrieves the prices in `targetCurrency` of the crypto-currencies identified by `ids`, keyed by id
+	FetchPrices(ids []string, targetCurrency string) (prices map[string]float64, err error)
+}
+
+// HistoricalPriceProvider is implemented by price providers which can retrieve the daily price of a crypto-currency on a past date
+type HistoricalPriceProvider interface {
+	// FetchHistoricalPrice retrieves the price in `targetCurrency` of the crypto-currency identified by `id` on the date of `at`
+	FetchHistoricalPrice(id string, targetCurrency string, at time.Time) (price float64, err error)
+}
+
+// PriceProviderFactory creates a PriceProvider from an HTTP client instance
+type PriceProviderFactory func(client HTTPClient) PriceProvider
+
+var (
+	priceProvidersMutex sync.RWMutex
+	priceProviders      = make(map[string]PriceProviderFactory)
+)
+
+// RegisterPriceProvider makes a price provider factory available under the name `name`.
+// It panics if a price provider is registered twice under the same name.
+func RegisterPriceProvider(name string, factory PriceProviderFactory) {
+	priceProvidersMutex.Lock()
+	defer priceProvidersMutex.Unlock()
+
+	if factory == nil {
+		panic("fetchers: RegisterPriceProvider factory is nil")
+	}
+	if _, duplicate := priceProviders[name]; duplicate {
+		panic("fetchers: RegisterPriceProvider called twice for price provider " + name)
+	}
+	priceProviders[name] = factory
+}
+
+// PriceProviders returns a sorted list of the names of the registered price providers
+func PriceProviders() []string {
+	priceProvidersMutex.RLock()
+	defer priceProvidersMutex.RUnlock()
+
+	names := make([]string, 0, len(priceProviders))
+	for name := range priceProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewPriceProvider creates the PriceProvider registered under the name `name`
+func NewPriceProvider(name string, client HTTPClient) (PriceProvider, error) {
+	priceProvidersMutex.RLock()
+	factory, ok := priceProviders[name]
+	priceProvidersMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown price provider %s", name)
+	}
+
+	return factory(client), nil
+}
+
+// BatchedPriceFetcher collects the crypto-currencies whose price is needed and fetches all of them from a PriceProvider in a single call per target currency
+type BatchedPriceFetcher struct {
+	provider PriceProvider
+
+	mutex   sync.Mutex
+	ids     []string
+	batches map[string]*priceBatch
+}
+
+type priceBatch struct {
+	once   sync.Once
+	prices map[string]float64
+	err    error
+}
+
+// NewBatchedPriceFetcher creates an instance of BatchedPriceFetcher for a PriceProvider
+func NewBatchedPriceFetcher(provider PriceProvider) *BatchedPriceFetcher {
+	return &BatchedPriceFetcher{provider: provider, batches: make(map[string]*priceBatch)}
+}
+
+// ExchangeRateFetcher registers the crypto-currency `symbol` in the batch and returns a CryptoCurrencyExchangeRateFetcher for it.
+// `id` overrides the identifier used by the price provider. All exchange rate fetchers must be created before the first price is fetched.
+func (batch *BatchedPriceFetcher) ExchangeRateFetcher(symbol string, id string) CryptoCurrencyExchangeRateFetcher {
+	if id == "" {
+		id = batch.provider.PriceID(symbol)
+	}
+
+	batch.mutex.Lock()
+	defer batch.mutex.Unlock()
+
+	batch.ids = append(batch.ids, id)
+
+	return &batchedExchangeRateFetcher{batch, symbol, id}
+}
+
+// prices returns the prices of all registered crypto-currencies in `targetCurrency`, fetching them on first use
+func (batch *BatchedPriceFetcher) prices(targetCurrency string) (map[string]float64, error) {
+	batch.mutex.Lock()
+	result, ok := batch.batches[targetCurrency]
+	if !ok {
+		result = &priceBatch{}
+		batch.batches[targetCurrency] = result
+	}
+	ids := uniqueStrings(batch.ids)
+	batch.mutex.Unlock()
+
+	result.once.Do(func() {
+		result.prices, result.err = batch.provider.FetchPrices(ids, targetCurrency)
+	})
+
+	return result.prices, result.err
+}
+
+// batchedExchangeRateFetcher implements CryptoCurrencyExchangeRateFetcher for one crypto-currency of a BatchedPriceFetcher
+type batchedExchangeRateFetcher struct {
+	batch  *BatchedPriceFetcher
+	symbol string
+	id     string
+}
+
+// FetchExchangeRate retrieves the exchange rate in `targetCurrency` from the batch of prices
+func (fetcher *batchedExchangeRateFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate = 0.
+
+	prices, fetchErr := fetcher.batch.prices(targetCurrency)
+	if fetchErr != nil {
+		*err = fetchErr
+		return
+	}
+
+	price, ok := prices[fetcher.id]
+	if !ok {
+		*err = errorf(UnsupportedCurrencyError, "no %s price available for %s (%s)", strings.ToUpper(targetCurrency), fetcher.symbol, fetcher.id)
+		return
+	}
+
+	*exchangeRate, *err = price, nil
+}
+
+// FetchExchangeRateAt retrieves the daily exchange rate in `targetCurrency` on the date of `at`, if the price provider supports historical prices
+func (fetcher *batchedExchangeRateFetcher) FetchExchangeRateAt(apiKey string, targetCurrency string, at time.Time, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	defer done.Done()
+
+	*exchangeRate = 0.
+
+	historicalProvider, ok := fetcher.batch.provider.(HistoricalPriceProvider)
+	if !ok {
+		*err = fmt.Errorf("historical prices are not supported by %T", fetcher.batch.provider)
+		return
+	}
+
+	*exchangeRate, *err = historicalProvider.FetchHistoricalPrice(fetcher.id, targetCurrency, at)
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+
+	return unique
+}
@@ -0,0 +1,96 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchedPriceFetcherFetchesAllPricesInOneCall(t *testing.T) {
+	cases := []struct {
+		name           string
+		priceProvider  string
+		url            string
+		returnedBody   string
+		expectedPrices map[string]float64
+	}{
+		{"coingecko", fetchers.CoinGeckoPriceProviderName,
+			"https://api.coingecko.com/api/v3/simple/price?ids=bitcoin%2Cethereum%2Cnocoin&vs_currencies=usd",
+			`{"bitcoin":{"usd":11803.59},"ethereum":{"usd":457.23}}`,
+			map[string]float64{"BTC": 11803.59, "ETH": 457.23},
+		},
+		{"binance", fetchers.BinancePriceProviderName,
+			"https://api.binance.com/api/v3/ticker/price?symbols=%5B%22BTCUSDT%22%2C%22ETHUSDT%22%2C%22NOCOINUSDT%22%5D",
+			`[{"symbol":"BTCUSDT","price":"11803.59"},{"symbol":"ETHUSDT","price":"457.23"}]`,
+			map[string]float64{"BTC": 11803.59, "ETH": 457.23},
+		},
+	}
+
+	for _, testCase := range cases {
+		clientMock := new(mockHTTPClient)
+		clientMock.On("Get", testCase.url).Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(testCase.returnedBody))}, nil).Once()
+
+		priceProvider, err := fetchers.NewPriceProvider(testCase.priceProvider, clientMock)
+		require.NoError(t, err, testCase.name)
+
+		batch := fetchers.NewBatchedPriceFetcher(priceProvider)
+		exchangeRateFetchers := map[string]fetchers.CryptoCurrencyExchangeRateFetcher{
+			"BTC":    batch.ExchangeRateFetcher("BTC", ""),
+			"ETH":    batch.ExchangeRateFetcher("ETH", ""),
+			"NOCOIN": batch.ExchangeRateFetcher("NOCOIN", ""),
+		}
+
+		exchangeRates := make(map[string]*float64)
+		errs := make(map[string]*error)
+		var wg sync.WaitGroup
+		for symbol, exchangeRateFetcher := range exchangeRateFetchers {
+			exchangeRates[symbol], errs[symbol] = new(float64), new(error)
+			wg.Add(1)
+			go exchangeRateFetcher.FetchExchangeRate("", "usd", exchangeRates[symbol], errs[symbol], &wg)
+		}
+		wg.Wait()
+
+		for symbol, expectedPrice := range testCase.expectedPrices {
+			require.NoError(t, *errs[symbol], testCase.name)
+			require.Equal(t, expectedPrice, *exchangeRates[symbol], testCase.name)
+		}
+		require.Error(t, *errs["NOCOIN"], testCase.name)
+
+		clientMock.AssertExpectations(t)
+	}
+}
+
+func TestBinancePriceProviderSkipsUnlistedPairs(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	invalidSymbol := `{"code":-1121,"msg":"Invalid symbol."}`
+	clientMock.On("Get", "https://api.binance.com/api/v3/ticker/price?symbols=%5B%22BTCUSDT%22%2C%22UNOUSDT%22%2C%22ETHUSDT%22%5D").Return(&http.Response{Status: "400", StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(invalidSymbol))}, nil).Once()
+	clientMock.On("Get", "https://api.binance.com/api/v3/ticker/price?symbol=BTCUSDT").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"symbol":"BTCUSDT","price":"11803.59"}`))}, nil).Once()
+	clientMock.On("Get", "https://api.binance.com/api/v3/ticker/price?symbol=UNOUSDT").Return(&http.Response{Status: "400", StatusCode: 400, Body: ioutil.NopCloser(bytes.NewBufferString(invalidSymbol))}, nil).Once()
+	clientMock.On("Get", "https://api.binance.com/api/v3/ticker/price?symbol=ETHUSDT").Return(&http.Response{Status: "200", StatusCode: 200, Body: ioutil.NopCloser(bytes.NewBufferString(`{"symbol":"ETHUSDT","price":"457.23"}`))}, nil).Once()
+
+	prices, err := fetchers.NewBinancePriceProvider(clientMock).FetchPrices([]string{"BTC", "UNO", "ETH"}, "usd")
+
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"BTC": 11803.59, "ETH": 457.23}, prices)
+	clientMock.AssertExpectations(t)
+}
+
+func TestBinancePriceProviderReportsOtherErrors(t *testing.T) {
+	clientMock := new(mockHTTPClient)
+	clientMock.On("Get", "https://api.binance.com/api/v3/ticker/price?symbols=%5B%22BTCUSDT%22%2C%22ETHUSDT%22%5D").Return(&http.Response{Status: "429", StatusCode: 429, Body: ioutil.NopCloser(bytes.NewBufferString(`{"code":-1003,"msg":"Too many requests."}`))}, nil).Once()
+
+	_, err := fetchers.NewBinancePriceProvider(clientMock).FetchPrices([]string{"BTC", "ETH"}, "usd")
+
+	require.Equal(t, fetchers.RateLimitedError, fetchers.ErrorKindOf(err))
+	clientMock.AssertExpectations(t)
+}
+
+func TestNewPriceProviderUnknownName(t *testing.T) {
+	_, err := fetchers.NewPriceProvider("nowhere", new(mockHTTPClient))
+	require.EqualError(t, err, "unknown price provider nowhere")
+}
@@ -0,0 +1,71 @@
+package fetchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// InfoFetcherFactory creates a CryptoCurrencyInfoFetcher for the crypto-currency identified by `symbol`, using provider-specific `options`
+type InfoFetcherFactory func(symbol string, client HTTPClient, options json.RawMessage) (CryptoCurrencyInfoFetcher, error)
+
+var (
+	providersMutex sync.RWMutex
+	providers      = make(map[string]InfoFetcherFactory)
+)
+
+// RegisterProvider makes an info fetcher factory available under the provider name `name`.
+// It panics if a provider is registered twice under the same name.
+func RegisterProvider(name string, factory InfoFetcherFactory) {
+	providersMutex.Lock()
+	defer providersMutex.Unlock()
+
+	if factory == nil {
+		panic("fetchers: RegisterProvider factory is nil")
+	}
+	if _, duplicate := providers[name]; duplicate {
+		panic("fetchers: RegisterProvider called twice for provider " + name)
+	}
+	providers[name] = factory
+}
+
+// Providers returns a sorted list of the names of the registered providers
+func Providers() []string {
+	providersMutex.RLock()
+	defer providersMutex.RUnlock()
+
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NewInfoFetcher creates a CryptoCurrencyInfoFetcher for `symbol` using the factory registered under the provider name `provider`
+func NewInfoFetcher(provider string, symbol string, client HTTPClient, options json.RawMessage) (CryptoCurrencyInfoFetcher, error) {
+	providersMutex.RLock()
+	factory, ok := providers[provider]
+	providersMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %s", provider)
+	}
+
+	return factory(symbol, client, options)
+}
+
+// decodeProviderOptions unmarshals provider-specific options into `options`, leaving it untouched if none were specified
+func decodeProviderOptions(provider string, rawOptions json.RawMessage, options interface{}) error {
+	if len(rawOptions) == 0 || string(rawOptions) == "null" {
+		return nil
+	}
+
+	if err := json.Unmarshal(rawOptions, options); err != nil {
+		return fmt.Errorf("invalid options for provider %s: %s", provider, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package fetchers_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewInfoFetcher(t *testing.T) {
+	cases := []struct {
+		name                 string
+		provider             string
+		symbol               string
+		options              string
+		expectedErrorMessage string
+		expectedType         interface{}
+	}{
+		{"blockchain.info for BTC", fetchers.BlockchainInfoProvider, "BTC", "", "", &fetchers.BlockchainInfoFetcher{}},
+		{"blockchain.info for LTC", fetchers.BlockchainInfoProvider, "LTC", "", "blockchain.info only supports BTC, not LTC", nil},
+		{"etherscan for ETH", fetchers.EtherscanProvider, "ETH", "", "", &fetchers.EtherscanInfoFetcher{}},
+		{"etherscan for MATIC", fetchers.EtherscanProvider, "MATIC", "", "", &fetchers.EtherscanInfoFetcher{}},
+		{"etherscan with chain option", fetchers.EtherscanProvider, "ETH", `{"chain": "arbitrum"}`, "", &fetchers.EtherscanInfoFetcher{}},
+		{"etherscan with custom base URL", fetchers.EtherscanProvider, "FTM", `{"base_url": "https://api.ftmscan.com", "price_action": "ftmprice", "price_field": "ftmusd"}`, "", &fetchers.EtherscanInfoFetcher{}},
+		{"etherscan chain with other native currency", fetchers.EtherscanProvider, "ETH", `{"chain": "polygon"}`, "etherscan chain polygon only supports MATIC, not ETH", nil},
+		{"etherscan with unknown chain", fetchers.EtherscanProvider, "ETH", `{"chain": "nowhere"}`, "unknown etherscan chain nowhere", nil},
+		{"etherscan for LTC", fetchers.EtherscanProvider, "LTC", "", "etherscan requires a chain or base_url option for LTC", nil},
+		{"cryptoid for DASH", fetchers.CryptoidProvider, "DASH", "", "", &fetchers.CryptoidInfoFetcher{}},
+		{"cryptoid with currency option", fetchers.CryptoidProvider, "BCH", `{"currency": "bcc"}`, "", &fetchers.CryptoidInfoFetcher{}},
+		{"cryptoid with invalid options", fetchers.CryptoidProvider, "BCH", `{"currency": 1}`, "invalid options for provider cryptoid: json: cannot unmarshal number into Go struct field cryptoidOptions.currency of type string", nil},
+		{"blockchair for BCH", fetchers.BlockchairProvider, "BCH", "", "", &fetchers.BlockchairInfoFetcher{}},
+		{"blockchair with chain option", fetchers.BlockchairProvider, "XYZ", `{"chain": "zcash"}`, "", &fetchers.BlockchairInfoFetcher{}},
+		{"blockchair for unknown chain", fetchers.BlockchairProvider, "XYZ", "", "blockchair requires a chain option for XYZ", nil},
+		{"esplora for BTC", fetchers.EsploraProvider, "BTC", "", "", &fetchers.EsploraInfoFetcher{}},
+		{"esplora for Liquid", fetchers.EsploraProvider, "LBTC", "", "", &fetchers.EsploraInfoFetcher{}},
+		{"esplora with base URL", fetchers.EsploraProvider, "BTC", `{"base_url": "https://mempool.space/api"}`, "", &fetchers.EsploraInfoFetcher{}},
+		{"esplora for LTC", fetchers.EsploraProvider, "LTC", "", "esplora requires a base_url option for LTC", nil},
+		{"blockbook for DOGE", fetchers.BlockbookProvider, "DOGE", "", "", &fetchers.BlockbookInfoFetcher{}},
+		{"blockbook with instance URL", fetchers.BlockbookProvider, "XYZ", `{"url": "https://blockbook.local"}`, "", &fetchers.BlockbookInfoFetcher{}},
+		{"blockbook for unknown coin", fetchers.BlockbookProvider, "XYZ", "", "blockbook requires a url option for XYZ", nil},
+		{"unknown provider", "nowhere", "BTC", "", "unknown provider nowhere", nil},
+	}
+
+	for _, testCase := range cases {
+		infoFetcher, err := fetchers.NewInfoFetcher(testCase.provider, testCase.symbol, new(mockHTTPClient), json.RawMessage(testCase.options))
+		if testCase.expectedErrorMessage != "" {
+			require.EqualError(t, err, testCase.expectedErrorMessage, testCase.name)
+			require.Nil(t, infoFetcher, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+			require.IsType(t, testCase.expectedType, infoFetcher, testCase.name)
+		}
+	}
+}
+
+func TestProvidersListsBuiltInProviders(t *testing.T) {
+	require.Subset(t, fetchers.Providers(), []string{fetchers.BlockchainInfoProvider, fetchers.CryptoidProvider, fetchers.EtherscanProvider})
+}
@@ -0,0 +1,73 @@
+package fetchers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// RecordingHTTPClient is an HTTPClient which sends requests with another client and records each response as an HTTPFixture,
+// so that the session can later be replayed with ReplayingHTTPClient
+type RecordingHTTPClient struct {
+	client    HTTPDoer
+	directory string
+
+	mutex sync.Mutex
+}
+
+// NewRecordingHTTPClient creates an instance of RecordingHTTPClient sending requests with `client` and recording them into `directory`
+func NewRecordingHTTPClient(client HTTPDoer, directory string) *RecordingHTTPClient {
+	return &RecordingHTTPClient{client: client, directory: directory}
+}
+
+// Get performs a GET request on `url` and records its response
+func (client *RecordingHTTPClient) Get(url string) (resp *http.Response, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+}
+
+// Do sends `req` and records its response. Requests which fail without a response are not recorded
+func (client *RecordingHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp, err = client.client.Do(req); err != nil {
+		return
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(strings.NewReader(string(body)))
+
+	header := resp.Header.Clone()
+	header.Del("Set-Cookie")
+	fixture := &HTTPFixture{
+		Method:      req.Method,
+		URL:         redactURL(req.URL.String()),
+		RequestBody: requestBody,
+		StatusCode:  resp.StatusCode,
+		Header:      header,
+		Body:        string(body),
+	}
+
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	fileName := httpFixtureFileName(req.Method, req.URL.String(), requestBody)
+	if err = fixture.save(client.directory, fileName); err != nil {
+		return nil, err
+	}
+	logger().Debug("recorded response", "url", logURL(req.URL.String()), "status", resp.StatusCode, "fixture", fileName)
+
+	return resp, nil
+}
@@ -0,0 +1,47 @@
+package fetchers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ReplayingHTTPClient is an HTTPClient which answers requests from the HTTP fixtures recorded by RecordingHTTPClient, without any network access.
+// Requests without a recorded response fail
+type ReplayingHTTPClient struct {
+	directory string
+}
+
+// NewReplayingHTTPClient creates an instance of ReplayingHTTPClient serving the fixtures recorded in `directory`
+func NewReplayingHTTPClient(directory string) *ReplayingHTTPClient {
+	return &ReplayingHTTPClient{directory: directory}
+}
+
+// Get replays the response recorded for a GET request on `url`
+func (client *ReplayingHTTPClient) Get(url string) (resp *http.Response, err error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Do(req)
+}
+
+// Do replays the response recorded for `req`
+func (client *ReplayingHTTPClient) Do(req *http.Request) (resp *http.Response, err error) {
+	requestBody, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := httpFixtureFileName(req.Method, req.URL.String(), requestBody)
+	fixture, err := loadHTTPFixture(client.directory, fileName)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no recorded response for %s %s in %s", req.Method, redactURL(req.URL.String()), client.directory)
+	} else if err != nil {
+		return nil, err
+	}
+	logger().Debug("replayed response", "url", logURL(req.URL.String()), "status", fixture.StatusCode, "fixture", fileName)
+
+	return fixture.response(req), nil
+}
@@ -0,0 +1,174 @@
+package fetchers_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHTTPDoer answers requests from canned response bodies keyed by method and URL, and fails any other request
+type fakeHTTPDoer map[string]string
+
+func (doer fakeHTTPDoer) Do(req *http.Request) (*http.Response, error) {
+	body, ok := doer[req.Method+" "+req.URL.String()]
+	if !ok {
+		return nil, fmt.Errorf("unexpected request %s %s", req.Method, req.URL)
+	}
+
+	return &http.Response{Status: "200 OK", StatusCode: 200, Header: http.Header{"Content-Type": {"application/json"}}, Body: ioutil.NopCloser(bytes.NewBufferString(body))}, nil
+}
+
+// replayCase describes a session of a provider, whose fixtures are stored in testdata/fixtures/<provider>
+type replayCase struct {
+	provider             string
+	symbol               string
+	options              string
+	addresses            []string
+	apiKey               string
+	responses            fakeHTTPDoer
+	expectedBalance      float64
+	expectedExchangeRate float64
+}
+
+var replayCases = []replayCase{
+	{fetchers.BlockchainInfoProvider, "BTC", "", []string{"1A", "1B"}, "", fakeHTTPDoer{
+		"GET https://blockchain.info/q/addressbalance/1A%7C1B":   "150000000",
+		"GET https://blockchain.info/tobtc?currency=usd&value=1": "0.00002",
+	}, 1.5, 50000},
+	{fetchers.EtherscanProvider, "ETH", "", []string{"0xa", "0xb"}, "key", fakeHTTPDoer{
+		"GET https://api.etherscan.io/api?module=account&action=balancemulti&address=0xa,0xb&tag=latest": `{"status":"1","message":"OK","result":[{"account":"0xa","balance":"1000000000000000000"},{"account":"0xb","balance":"500000000000000000"}]}`,
+		"GET https://api.etherscan.io/api?module=stats&action=ethprice&apikey=key":                       `{"status":"1","message":"OK","result":{"ethbtc":"0.05","ethusd":"3000.5"}}`,
+	}, 1.5, 3000.5},
+	{fetchers.CryptoidProvider, "LTC", "", []string{"La"}, "key", fakeHTTPDoer{
+		"GET https://chainz.cryptoid.info/ltc/api.dws?q=getbalance&key=key&a=La": "10.5",
+		"GET https://chainz.cryptoid.info/ltc/api.dws?q=ticker.usd&key=key":      "75.25",
+	}, 10.5, 75.25},
+	{fetchers.BitcoindProvider, "BTC", `{"url": "http://127.0.0.1:8332", "user": "alice", "password": "secret"}`, []string{"bc1a"}, "", fakeHTTPDoer{
+		"POST http://127.0.0.1:8332": `{"result":{"success":true,"total_amount":0.25},"error":null,"id":1}`,
+	}, 0.25, 0},
+	{fetchers.EsploraProvider, "BTC", "", []string{"bc1a", "bc1b"}, "", fakeHTTPDoer{
+		"GET https://blockstream.info/api/address/bc1a": `{"address":"bc1a","chain_stats":{"funded_txo_sum":300000000,"spent_txo_sum":100000000},"mempool_stats":{"funded_txo_sum":0,"spent_txo_sum":0}}`,
+		"GET https://blockstream.info/api/address/bc1b": `{"address":"bc1b","chain_stats":{"funded_txo_sum":50000000,"spent_txo_sum":0},"mempool_stats":{"funded_txo_sum":0,"spent_txo_sum":0}}`,
+	}, 2.5, 0},
+	{fetchers.BlockbookProvider, "BTC", "", []string{"bc1a", "bc1b"}, "", fakeHTTPDoer{
+		"GET https://btc1.trezor.io/api/v2/address/bc1a?details=basic": `{"address":"bc1a","balance":"100000000","unconfirmedBalance":"0"}`,
+		"GET https://btc1.trezor.io/api/v2/address/bc1b?details=basic": `{"address":"bc1b","balance":"25000000","unconfirmedBalance":"0"}`,
+		"GET https://btc1.trezor.io/api/v2/tickers?currency=usd":       `{"ts":1600000000,"rates":{"usd":50000.5}}`,
+	}, 1.25, 50000.5},
+	{fetchers.BlockchairProvider, "BCH", "", []string{"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"}, "key", fakeHTTPDoer{
+		"GET https://api.blockchair.com/bitcoin-cash/addresses/balances?addresses=qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a,qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy&key=key": `{"data":{"qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a":100000000,"qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy":75000000}}`,
+		"GET https://api.blockchair.com/bitcoin-cash/stats": `{"data":{"market_price_usd":2512.5}}`,
+	}, 1.75, 2512.5},
+	{fetchers.EthereumRPCProvider, "ETH", `{"url": "http://127.0.0.1:8545"}`, []string{"0xa", "0xb"}, "", fakeHTTPDoer{
+		"POST http://127.0.0.1:8545": `[{"jsonrpc":"2.0","id":0,"result":"0xde0b6b3a7640000"},{"jsonrpc":"2.0","id":1,"result":"0x6f05b59d3b20000"}]`,
+	}, 1.5, 0},
+	{fetchers.GenericHTTPProvider, "DOGE", `{"balance_url": "https://dogechain.local/balance/{address}", "rate_url": "https://dogechain.local/price/{currency}", "balance_response": "number", "rate_response": "number", "divisor": 100000000}`, []string{"Da", "Db"}, "", fakeHTTPDoer{
+		"GET https://dogechain.local/balance/Da": "1000000000",
+		"GET https://dogechain.local/balance/Db": "500000000",
+		"GET https://dogechain.local/price/usd":  "0.0625",
+	}, 15, 0.0625},
+	{fetchers.CoinGeckoPriceProviderName, "BTC", "", nil, "", fakeHTTPDoer{
+		"GET https://api.coingecko.com/api/v3/simple/price?ids=bitcoin&vs_currencies=usd": `{"bitcoin":{"usd":11803.59}}`,
+	}, 0, 11803.59},
+	{fetchers.BinancePriceProviderName, "BTC", "", nil, "", fakeHTTPDoer{
+		"GET https://api.binance.com/api/v3/ticker/price?symbols=%5B%22BTCUSDT%22%5D": `[{"symbol":"BTCUSDT","price":"11803.59"}]`,
+	}, 0, 11803.59},
+}
+
+// isPriceProvider returns true if `name` is a registered price provider, whose sessions only fetch an exchange rate
+func isPriceProvider(name string) bool {
+	for _, priceProvider := range fetchers.PriceProviders() {
+		if priceProvider == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fetchBalanceAndRate fetches the balance and USD exchange rate of `addresses` with the provider of `testCase`, sending requests with `client`
+func fetchBalanceAndRate(t *testing.T, testCase replayCase, client fetchers.HTTPClient, apiKey string) (balance float64, exchangeRate float64) {
+	if isPriceProvider(testCase.provider) {
+		priceProvider, err := fetchers.NewPriceProvider(testCase.provider, client)
+		require.NoError(t, err, testCase.provider)
+
+		var exchangeRateErr error
+		done := &sync.WaitGroup{}
+		done.Add(1)
+		fetchers.NewBatchedPriceFetcher(priceProvider).ExchangeRateFetcher(testCase.symbol, "").FetchExchangeRate(apiKey, "usd", &exchangeRate, &exchangeRateErr, done)
+		require.NoError(t, exchangeRateErr, testCase.provider)
+
+		return
+	}
+
+	var options []byte
+	if testCase.options != "" {
+		options = []byte(testCase.options)
+	}
+	fetcher, err := fetchers.NewInfoFetcher(testCase.provider, testCase.symbol, client, options)
+	require.NoError(t, err, testCase.provider)
+
+	var balanceErr, exchangeRateErr error
+	done := &sync.WaitGroup{}
+	done.Add(1)
+	fetcher.FetchBalance(testCase.addresses, apiKey, &balance, &balanceErr, done)
+	require.NoError(t, balanceErr, testCase.provider)
+
+	if testCase.expectedExchangeRate != 0 {
+		done.Add(1)
+		fetcher.FetchExchangeRate(apiKey, "usd", &exchangeRate, &exchangeRateErr, done)
+		require.NoError(t, exchangeRateErr, testCase.provider)
+	}
+
+	return
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	for _, testCase := range replayCases {
+		directory := t.TempDir()
+
+		balance, exchangeRate := fetchBalanceAndRate(t, testCase, fetchers.NewRecordingHTTPClient(testCase.responses, directory), testCase.apiKey)
+		require.Equal(t, testCase.expectedBalance, balance, testCase.provider)
+		require.InDelta(t, testCase.expectedExchangeRate, exchangeRate, 1e-6, testCase.provider)
+
+		files, err := ioutil.ReadDir(directory)
+		require.NoError(t, err)
+		require.Len(t, files, len(testCase.responses), testCase.provider)
+		for _, file := range files {
+			fixture, err := ioutil.ReadFile(directory + "/" + file.Name())
+			require.NoError(t, err)
+			require.NotContains(t, string(fixture), "apikey=key", testCase.provider)
+			require.NotContains(t, string(fixture), "secret", testCase.provider)
+		}
+
+		// The replayed session doesn't touch the network, and matches requests regardless of the API key
+		balance, exchangeRate = fetchBalanceAndRate(t, testCase, fetchers.NewReplayingHTTPClient(directory), "other-key")
+		require.Equal(t, testCase.expectedBalance, balance, testCase.provider)
+		require.InDelta(t, testCase.expectedExchangeRate, exchangeRate, 1e-6, testCase.provider)
+	}
+}
+
+func TestReplayFixtures(t *testing.T) {
+	for _, testCase := range replayCases {
+		client := fetchers.NewReplayingHTTPClient("testdata/fixtures/" + testCase.provider)
+		balance, exchangeRate := fetchBalanceAndRate(t, testCase, client, testCase.apiKey)
+		require.Equal(t, testCase.expectedBalance, balance, testCase.provider)
+		require.InDelta(t, testCase.expectedExchangeRate, exchangeRate, 1e-6, testCase.provider)
+	}
+}
+
+func TestReplayMissingFixture(t *testing.T) {
+	client := fetchers.NewReplayingHTTPClient(t.TempDir())
+
+	_, err := fetchers.NewWebNumberFetcher(client).Fetch("https://blockchain.info/q/addressbalance/1C?apikey=key")
+
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no recorded response for GET https://blockchain.info/q/addressbalance/1C?apikey=REDACTED")
+	require.Equal(t, fetchers.UnavailableError, fetchers.ErrorKindOf(err))
+}
@@ -0,0 +1,78 @@
+package fetchers
+
+import (
+	"sort"
+	"time"
+)
+
+// Transaction is a crypto-currency transaction normalized across providers
+type Transaction struct {
+	TxID          string
+	Time          time.Time
+	Movements     []*AddressMovement
+	Fee           float64
+	Confirmations int
+}
+
+// AddressMovement holds the amounts received and sent by one of the queried addresses in a transaction. The amount sent includes the fee paid by the address
+type AddressMovement struct {
+	Address string
+	In      float64
+	Out     float64
+}
+
+// NetAmount returns the net amount received by the queried addresses in the transaction (negative when funds were sent)
+func (tx *Transaction) NetAmount() (amount float64) {
+	for _, movement := range tx.Movements {
+		amount += movement.In - movement.Out
+	}
+
+	return
+}
+
+// movement returns the movement for `address`, adding it to the transaction if needed
+func (tx *Transaction) movement(address string) *AddressMovement {
+	for _, movement := range tx.Movements {
+		if movement.Address == address {
+			return movement
+		}
+	}
+
+	movement := &AddressMovement{Address: address}
+	tx.Movements = append(tx.Movements, movement)
+
+	return movement
+}
+
+// transactionSet merges the transactions seen from several addresses by transaction id
+type transactionSet struct {
+	byID map[string]*Transaction
+}
+
+func newTransactionSet() *transactionSet {
+	return &transactionSet{make(map[string]*Transaction)}
+}
+
+// get returns the transaction with id `txID`, creating it if it wasn't seen before
+func (set *transactionSet) get(txID string) *Transaction {
+	tx, ok := set.byID[txID]
+	if !ok {
+		tx = &Transaction{TxID: txID}
+		set.byID[txID] = tx
+	}
+
+	return tx
+}
+
+// sorted returns the transactions, most recent first
+func (set *transactionSet) sorted() []*Transaction {
+	transactions := make([]*Transaction, 0, len(set.byID))
+	for _, tx := range set.byID {
+		transactions = append(transactions, tx)
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Time.After(transactions[j].Time)
+	})
+
+	return transactions
+}
@@ -0,0 +1,167 @@
+package lots
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Method identifies how disposals are matched against acquisition lots
+type Method string
+
+const (
+	// FIFO matches disposals against the oldest lots first
+	FIFO Method = "fifo"
+	// LIFO matches disposals against the most recent lots first
+	LIFO Method = "lifo"
+	// AverageCost values disposals at the average cost of all lots held
+	AverageCost Method = "average"
+)
+
+// ParseMethod returns the Method named `name`, defaulting to FIFO when `name` is empty
+func ParseMethod(name string) (Method, error) {
+	switch method := Method(name); method {
+	case "":
+		return FIFO, nil
+	case FIFO, LIFO, AverageCost:
+		return method, nil
+	default:
+		return "", fmt.Errorf("unknown lot matching method %s, expected %s, %s or %s", name, FIFO, LIFO, AverageCost)
+	}
+}
+
+// Lot is an amount of crypto-currency acquired at a given time and unit cost
+type Lot struct {
+	Acquired time.Time
+	Amount   float64
+	UnitCost float64
+}
+
+// Disposal is an amount of crypto-currency disposed of, matched against a single acquisition lot
+type Disposal struct {
+	Acquired  time.Time
+	Disposed  time.Time
+	Amount    float64
+	Proceeds  float64
+	CostBasis float64
+	// Unmatched is true when the disposal exceeded the tracked lots, in which case its cost basis is zero
+	Unmatched bool
+}
+
+// Gain returns the realized gain (or loss, if negative) of the disposal
+func (disposal *Disposal) Gain() float64 {
+	return disposal.Proceeds - disposal.CostBasis
+}
+
+// HoldingPeriod returns the time between acquisition and disposal
+func (disposal *Disposal) HoldingPeriod() time.Duration {
+	if disposal.Unmatched {
+		return 0
+	}
+
+	return disposal.Disposed.Sub(disposal.Acquired)
+}
+
+// Ledger tracks the acquisition lots of a crypto-currency and matches disposals against them
+type Ledger struct {
+	method    Method
+	lots      []*Lot
+	disposals []*Disposal
+}
+
+// NewLedger creates an empty Ledger which matches disposals using `method`
+func NewLedger(method Method) *Ledger {
+	return &Ledger{method: method}
+}
+
+// Acquire records the acquisition of `amount` at `unitCost` at time `at`. Events must be recorded in chronological order
+func (ledger *Ledger) Acquire(at time.Time, amount, unitCost float64) {
+	if amount <= 0 {
+		return
+	}
+
+	ledger.lots = append(ledger.lots, &Lot{Acquired: at, Amount: amount, UnitCost: unitCost})
+
+	if ledger.method == AverageCost {
+		ledger.averageLots()
+	}
+}
+
+// Dispose records the disposal of `amount` at `unitPrice` at time `at`, matching it against the lots held. Events must be recorded in chronological order
+func (ledger *Ledger) Dispose(at time.Time, amount, unitPrice float64) {
+	for amount > 0 && len(ledger.lots) > 0 {
+		index := 0
+		if ledger.method == LIFO {
+			index = len(ledger.lots) - 1
+		}
+		lot := ledger.lots[index]
+
+		matched := amount
+		if lot.Amount < matched {
+			matched = lot.Amount
+		}
+
+		ledger.disposals = append(ledger.disposals, &Disposal{
+			Acquired:  lot.Acquired,
+			Disposed:  at,
+			Amount:    matched,
+			Proceeds:  matched * unitPrice,
+			CostBasis: matched * lot.UnitCost,
+		})
+
+		lot.Amount -= matched
+		amount -= matched
+		if lot.Amount <= 0 {
+			ledger.lots = append(ledger.lots[:index], ledger.lots[index+1:]...)
+		}
+	}
+
+	if amount > 0 {
+		ledger.disposals = append(ledger.disposals, &Disposal{Disposed: at, Amount: amount, Proceeds: amount * unitPrice, Unmatched: true})
+	}
+}
+
+// averageLots sets the unit cost of all lots to their weighted average, keeping their acquisition dates
+func (ledger *Ledger) averageLots() {
+	holdings, costBasis := ledger.Holdings(), ledger.CostBasis()
+	if holdings <= 0 {
+		return
+	}
+
+	for _, lot := range ledger.lots {
+		lot.UnitCost = costBasis / holdings
+	}
+}
+
+// Holdings returns the amount of crypto-currency held across all lots
+func (ledger *Ledger) Holdings() (amount float64) {
+	for _, lot := range ledger.lots {
+		amount += lot.Amount
+	}
+
+	return
+}
+
+// CostBasis returns the total cost of the lots held
+func (ledger *Ledger) CostBasis() (cost float64) {
+	for _, lot := range ledger.lots {
+		cost += lot.Amount * lot.UnitCost
+	}
+
+	return
+}
+
+// Lots returns the lots held, oldest first
+func (ledger *Ledger) Lots() []*Lot {
+	lots := append([]*Lot(nil), ledger.lots...)
+	sort.SliceStable(lots, func(i, j int) bool {
+		return lots[i].Acquired.Before(lots[j].Acquired)
+	})
+
+	return lots
+}
+
+// Disposals returns the disposals recorded so far, in chronological order
+func (ledger *Ledger) Disposals() []*Disposal {
+	return append([]*Disposal(nil), ledger.disposals...)
+}
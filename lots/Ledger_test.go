@@ -0,0 +1,73 @@
+package lots_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/lots"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2017, 1, d, 0, 0, 0, 0, time.UTC) }
+
+	cases := []struct {
+		method            lots.Method
+		expectedHoldings  float64
+		expectedCostBasis float64
+		expectedGains     []float64
+		expectedAcquired  []time.Time
+	}{
+		// Buy 1 @ 100, buy 1 @ 200, sell 1.5 @ 300
+		{lots.FIFO, 0.5, 100., []float64{200., 50.}, []time.Time{day(1), day(2)}},
+		{lots.LIFO, 0.5, 50., []float64{100., 100.}, []time.Time{day(2), day(1)}},
+		{lots.AverageCost, 0.5, 75., []float64{150., 75.}, []time.Time{day(1), day(2)}},
+	}
+
+	for _, testCase := range cases {
+		ledger := lots.NewLedger(testCase.method)
+		ledger.Acquire(day(1), 1., 100.)
+		ledger.Acquire(day(2), 1., 200.)
+		ledger.Dispose(day(3), 1.5, 300.)
+
+		require.InDelta(t, testCase.expectedHoldings, ledger.Holdings(), 1e-9, string(testCase.method))
+		require.InDelta(t, testCase.expectedCostBasis, ledger.CostBasis(), 1e-9, string(testCase.method))
+
+		disposals := ledger.Disposals()
+		require.Len(t, disposals, len(testCase.expectedGains), string(testCase.method))
+		for index, disposal := range disposals {
+			require.InDelta(t, testCase.expectedGains[index], disposal.Gain(), 1e-9, string(testCase.method))
+			require.Equal(t, testCase.expectedAcquired[index], disposal.Acquired, string(testCase.method))
+			require.Equal(t, day(3), disposal.Disposed, string(testCase.method))
+		}
+	}
+}
+
+func TestLedgerDisposalExceedingLots(t *testing.T) {
+	ledger := lots.NewLedger(lots.FIFO)
+	ledger.Acquire(time.Unix(0, 0), 1., 100.)
+	ledger.Dispose(time.Unix(10, 0), 3., 50.)
+
+	disposals := ledger.Disposals()
+	require.Len(t, disposals, 2)
+	require.False(t, disposals[0].Unmatched)
+	require.Equal(t, -50., disposals[0].Gain())
+	require.True(t, disposals[1].Unmatched)
+	require.Equal(t, 2., disposals[1].Amount)
+	require.Equal(t, 100., disposals[1].Gain())
+	require.Equal(t, time.Duration(0), disposals[1].HoldingPeriod())
+	require.Equal(t, 0., ledger.Holdings())
+}
+
+func TestParseMethod(t *testing.T) {
+	method, err := lots.ParseMethod("")
+	require.NoError(t, err)
+	require.Equal(t, lots.FIFO, method)
+
+	method, err = lots.ParseMethod("average")
+	require.NoError(t, err)
+	require.Equal(t, lots.AverageCost, method)
+
+	_, err = lots.ParseMethod("hifo")
+	require.EqualError(t, err, "unknown lot matching method hifo, expected fifo, lifo or average")
+}
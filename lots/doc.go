@@ -0,0 +1,2 @@
+// Package lots provides tracking of crypto-currency acquisition lots to compute cost basis and realized gains
+package lots
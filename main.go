@@ -1,76 +1,211 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"runtime"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/PombeirP/wallet-balance/walletbalance"
 	"github.com/bradfitz/slice"
 	"github.com/fatih/color"
 )
 
+// configPath is the path of the configuration file listing the crypto-currency accounts
+var configPath string
+
+// redactAddressesInLogs hides the configured addresses from the logged requests when set
+var redactAddressesInLogs bool
+
+// recordDirectory and replayDirectory are the directories HTTP responses are recorded into or replayed from, when set
+var recordDirectory, replayDirectory string
+
 func init() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 }
 
 func main() {
-	fmt.Println("Fetching balances...")
+	os.Exit(run(os.Args[1:], color.Output))
+}
+
+// run runs the program with the command line arguments `args`, writing the report to `output`, and returns the exit code
+func run(args []string, output io.Writer) int {
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	asOfDate := flags.String("as-of", "", "value the portfolio as of the end of a past date (YYYY-MM-DD) instead of now")
+	costBasis := flags.Bool("cost-basis", false, "track the cost basis and unrealized profit/loss of the holdings")
+	flags.StringVar(&configPath, "config", "./config.json", "path of the configuration file")
+	logLevel := flags.String("log-level", "error", "minimum level of the logged messages: debug, info, warn or error")
+	logFormat := flags.String("log-format", "text", "format of the log written to stderr: text or json")
+	flags.BoolVar(&redactAddressesInLogs, "log-redact-addresses", false, "hide the configured addresses from the log (API keys are always hidden)")
+	flags.StringVar(&recordDirectory, "record", "", "record the HTTP responses of the providers as fixtures into this directory")
+	flags.StringVar(&replayDirectory, "replay", "", "replay the HTTP responses recorded with --record from this directory instead of contacting the providers")
+	timeout := flags.Duration("timeout", 2*time.Minute, "maximum time spent fetching balances, after which the holdings fetched so far are reported")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s [flags] [balances | transactions [flags] | tax-report [flags]]\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err == flag.ErrHelp {
+		return 0
+	} else if err != nil {
+		return 2
+	}
+
+	logger, err := newLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		fmt.Fprintln(output, err.Error())
+		return 2
+	}
+	slog.SetDefault(logger)
+	fetchers.SetLogger(logger)
+	defer fetchers.CloseElectrumConnections()
+
+	if recordDirectory != "" && replayDirectory != "" {
+		fmt.Fprintln(output, "--record cannot be combined with --replay")
+		return 2
+	}
+
+	switch command := flags.Arg(0); command {
+	case "", "balances":
+		return runBalancesCommand(output, *asOfDate, *costBasis, *timeout)
+	case "transactions":
+		return runTransactionsCommand(output, flags.Args()[1:])
+	case "tax-report":
+		return runTaxReportCommand(output, flags.Args()[1:])
+	default:
+		fmt.Fprintf(output, "unknown command %s\n", command)
+		flags.Usage()
+		return 2
+	}
+}
+
+// loadConfig loads the crypto-currency accounts from the configuration file
+func loadConfig() ([]*walletbalance.Config, error) {
+	currenciesConfig, err := walletbalance.LoadConfigFromJSONFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("loaded configuration", "path", configPath, "entries", len(currenciesConfig))
+
+	if redactAddressesInLogs {
+		var addresses []string
+		for _, currencyConfig := range currenciesConfig {
+			addresses = append(addresses, currencyConfig.Addresses...)
+		}
+		fetchers.RedactAddressesInLogs(addresses)
+	}
+
+	return currenciesConfig, nil
+}
+
+// runBalancesCommand reports the balance of every configured account to `output` and returns the exit code
+func runBalancesCommand(output io.Writer, asOfDate string, costBasis bool, timeout time.Duration) int {
+	var asOf time.Time
+	if asOfDate != "" {
+		if costBasis {
+			fmt.Fprintln(output, "--cost-basis cannot be combined with --as-of")
+			return 2
+		}
+
+		date, err := time.Parse(walletbalance.DateLayout, asOfDate)
+		if err != nil {
+			fmt.Fprintln(output, err.Error())
+			return 2
+		}
+		asOf = date.Add(24*time.Hour - time.Second)
+
+		fmt.Fprintf(output, "Fetching balances as of %s...\n", asOfDate)
+	} else {
+		fmt.Fprintln(output, "Fetching balances...")
+	}
 
 	// Load crypto-currency accounts
-	currenciesConfig, err := loadConfigFromJSONFile("./config.json")
+	currenciesConfig, err := loadConfig()
 	if err != nil {
-		fmt.Println(err.Error())
-		os.Exit(1)
+		fmt.Fprintln(output, err.Error())
+		return 1
 	}
 
-	results := make(chan *CryptoCurrencyBalanceReport, len(currenciesConfig))
-	client := &http.Client{Timeout: 10 * time.Second}
-	currencyInfoFetcherCreator := NewCryptoCurrencyInfoHTTPFetcherCreator(client)
-	go fetchBalanceReports(currenciesConfig, currencyInfoFetcherCreator, 3, results)
+	// Stop waiting for balances on interrupt or once the deadline passes, and report what was fetched so far
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	fetchCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	defer cancelTimeout()
 
-	// Wait for results
-	var reports []*CryptoCurrencyBalanceReport
-	for range currenciesConfig {
-		reports = append(reports, <-results)
+	client := walletbalance.NewClient(walletbalance.WithHTTPClient(newHTTPClient()))
+	var reports []*walletbalance.CryptoCurrencyBalanceReport
+	if asOf.IsZero() {
+		reports = client.FetchReports(fetchCtx, currenciesConfig)
+	} else {
+		reports = client.FetchReportsAt(fetchCtx, currenciesConfig, asOf)
 	}
 
-	// Sort balances
+	// Sort balances, listing holdings of unknown value last
 	slice.Sort(reports, func(i, j int) bool {
 		bi, bj := reports[i], reports[j]
-		return bi.Balance*bi.UsdExchangeRate > bj.Balance*bj.UsdExchangeRate
+		if bi.Priced() != bj.Priced() {
+			return bi.Priced()
+		}
+		if bi.Priced() && bi.UsdBalance() != bj.UsdBalance() {
+			return bi.UsdBalance() > bj.UsdBalance()
+		}
+		return bi.Label < bj.Label
 	})
 
-	printReports(reports)
+	if costBasis {
+		fmt.Fprintln(output, "Tracking cost basis...")
+		client.TrackCostBasis(ctx, reports)
+	}
+
+	printReports(output, reports, asOf, costBasis)
+
+	return 0
 }
 
-func fetchBalanceReports(currenciesConfig []*cryptoBalanceCheckerConfig, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator, workerCount int, results chan<- *CryptoCurrencyBalanceReport) {
-	jobs := make(chan *cryptoBalanceCheckerConfig, workerCount)
+// newLogger creates a logger writing messages of at least `level` to `output` in the text or JSON `format`
+func newLogger(output io.Writer, level string, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %s, expected debug, info, warn or error", level)
+	}
 
-	// Define worker
-	worker := func(jobs <-chan *cryptoBalanceCheckerConfig, results chan<- *CryptoCurrencyBalanceReport) {
-		for j := range jobs {
-			if infoFetcher, err := currencyInfoFetcherCreator.Create(j.Symbol); err == nil {
-				go FetchInfoForCryptoCurrency(j, infoFetcher, results)
-			} else {
-				results <- nil
-			}
-		}
+	options := &slog.HandlerOptions{Level: minLevel}
+	switch format {
+	case "text":
+		return slog.New(slog.NewTextHandler(output, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(output, options)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %s, expected text or json", format)
 	}
+}
 
-	// Start up some workers
-	for index := 0; index < cap(jobs); index++ {
-		go worker(jobs, results)
+// newHTTPClient creates the HTTP client used by the fetchers, which logs requests and retries those failing transiently.
+// Responses are recorded into recordDirectory, or replayed from replayDirectory without retries, when set
+func newHTTPClient() fetchers.HTTPClient {
+	if replayDirectory != "" {
+		return fetchers.NewLoggingHTTPClient(fetchers.NewReplayingHTTPClient(replayDirectory), 0, 0)
 	}
 
-	// Wait for all jobs to complete
-	for _, currencyConfig := range currenciesConfig {
-		jobs <- currencyConfig
+	client := fetchers.NewLoggingHTTPClient(&http.Client{Timeout: 10 * time.Second}, 2, time.Second)
+	if recordDirectory != "" {
+		return fetchers.NewRecordingHTTPClient(client, recordDirectory)
 	}
+
+	return client
 }
 
-func printReports(reports []*CryptoCurrencyBalanceReport) {
+// printReports writes the balance reports, followed by their totals, to `output`
+func printReports(output io.Writer, reports []*walletbalance.CryptoCurrencyBalanceReport, asOf time.Time, costBasis bool) {
 	// Calculate max symbol length for formatting
 	var maxSymbolLength int
 	for _, report := range reports {
@@ -80,29 +215,178 @@ func printReports(reports []*CryptoCurrencyBalanceReport) {
 	}
 
 	// Print report
-	totalUsdBalance := 0.
+	totalUsdBalance, totalCostBasis := 0., 0.
 	usdColor := color.New(color.FgHiGreen).SprintFunc()
 	cryptoColor := color.New(color.FgHiCyan).SprintFunc()
 	errorColor := color.New(color.FgHiRed).SprintFunc()
+	var unpricedSymbols []string
 	for _, report := range reports {
-		if report.Error != nil {
-			fmt.Fprintf(color.Output, "%s: %s\n", report.Symbol, errorColor(report.Error))
-		} else {
-			usdBalance := report.Balance * report.UsdExchangeRate
-			totalUsdBalance += usdBalance
+		if !report.Priced() {
+			unpricedSymbols = append(unpricedSymbols, report.Label)
+		}
 
-			cryptoBalanceString := fmt.Sprintf(fmt.Sprintf("%%%df", 13-len(report.Symbol)), report.Balance)
+		if report.BalanceError != nil {
+			fmt.Fprintf(output, "%s: %s\n", report.Label, errorColor(describeError(report.BalanceError)))
+		} else {
+			cryptoBalanceString := fmt.Sprintf(fmt.Sprintf("%%%df", 13-len(report.Label)), report.Balance)
 			cryptoTickerSymbolString := fmt.Sprintf(fmt.Sprintf("%%%ds", -maxSymbolLength), report.Symbol)
 
-			fmt.Fprintf(color.Output, "%[1]s balance: %[2]s %[3]s (in USD: %[4]s, %[5]s%[3]s = %[6]s)\n",
-				report.Symbol,
-				cryptoColor(cryptoBalanceString),
-				cryptoTickerSymbolString,
-				usdColor(fmt.Sprintf("%7.2f$", usdBalance)),
-				cryptoColor("1"),
-				usdColor(fmt.Sprintf("%.2f$", report.UsdExchangeRate)))
+			if report.ExchangeRateError != nil {
+				fmt.Fprintf(output, "%[1]s balance: %[2]s %[3]s (in USD: %[4]s)\n",
+					report.Label,
+					cryptoColor(cryptoBalanceString),
+					cryptoTickerSymbolString,
+					errorColor("unknown"))
+				fmt.Fprintf(output, "    exchange rate: %s\n", errorColor(describeError(report.ExchangeRateError)))
+			} else {
+				usdBalance := report.UsdBalance()
+				totalUsdBalance += usdBalance
+
+				fmt.Fprintf(output, "%[1]s balance: %[2]s %[3]s (in USD: %[4]s, %[5]s%[3]s = %[6]s)\n",
+					report.Label,
+					cryptoColor(cryptoBalanceString),
+					cryptoTickerSymbolString,
+					usdColor(fmt.Sprintf("%7.2f$", usdBalance)),
+					cryptoColor("1"),
+					usdColor(fmt.Sprintf("%.2f$", report.UsdExchangeRate)))
+			}
+			if report.Manual {
+				fmt.Fprintf(output, "    %s\n", describeManualHolding(report))
+			}
+			if report.PendingIncoming != 0 || report.PendingOutgoing != 0 {
+				fmt.Fprintf(output, "    pending: %s incoming, %s outgoing (less than %d confirmations)\n",
+					cryptoColor(fmt.Sprintf("+%f", report.PendingIncoming)),
+					cryptoColor(fmt.Sprintf("-%f", report.PendingOutgoing)),
+					report.Config.MinConfirmations)
+			}
+			if costBasis {
+				if report.CostBasisError != nil {
+					fmt.Fprintf(output, "    cost basis: %s\n", errorColor(report.CostBasisError))
+				} else if report.ExchangeRateError != nil {
+					totalCostBasis += report.CostBasis
+					fmt.Fprintf(output, "    cost basis: %s, unrealized P&L: %s\n",
+						usdColor(fmt.Sprintf("%.2f$", report.CostBasis)),
+						errorColor("unknown"))
+				} else {
+					totalCostBasis += report.CostBasis
+					fmt.Fprintf(output, "    cost basis: %s, unrealized P&L: %s\n",
+						usdColor(fmt.Sprintf("%.2f$", report.CostBasis)),
+						describeProfitAndLoss(report.UnrealizedProfitAndLoss(), report.ReturnPercentage()))
+				}
+			}
+		}
+		if len(report.ExchangeRateQuotes) > 1 {
+			fmt.Fprintf(output, "    %s\n", describeExchangeRateQuotes(report))
+		}
+	}
+	fmt.Fprintln(output, "------------------------------------------")
+	// Totals only include holdings whose value is known, so say which ones are left out
+	var exclusionNote string
+	if len(unpricedSymbols) > 0 {
+		exclusionNote = errorColor(fmt.Sprintf(" (excluding unpriced holdings: %s)", strings.Join(unpricedSymbols, ", ")))
+	}
+	if asOf.IsZero() {
+		fmt.Fprintf(output, "USD balance: %s%s\n", usdColor(fmt.Sprintf("%.2f$", totalUsdBalance)), exclusionNote)
+	} else {
+		fmt.Fprintf(output, "USD balance as of %s: %s%s\n", asOf.Format(walletbalance.DateLayout), usdColor(fmt.Sprintf("%.2f$", totalUsdBalance)), exclusionNote)
+	}
+	if costBasis {
+		// Only holdings whose cost basis is known are included in the overall profit/loss
+		var valueWithCostBasis, unpricedCostBasis float64
+		for _, report := range reports {
+			switch {
+			case report.CostBasisError != nil || report.BalanceError != nil:
+			case report.ExchangeRateError != nil:
+				unpricedCostBasis += report.CostBasis
+			default:
+				valueWithCostBasis += report.UsdBalance()
+			}
+		}
+		pricedCostBasis := totalCostBasis - unpricedCostBasis
+
+		returnPercentage := 0.
+		if pricedCostBasis != 0 {
+			returnPercentage = (valueWithCostBasis - pricedCostBasis) / pricedCostBasis * 100
+		}
+		fmt.Fprintf(output, "Cost basis: %s, unrealized P&L: %s%s\n",
+			usdColor(fmt.Sprintf("%.2f$", totalCostBasis)),
+			describeProfitAndLoss(valueWithCostBasis-pricedCostBasis, returnPercentage),
+			exclusionNote)
+	}
+}
+
+// describeEntryErrors describes the errors of the config entries, keyed by the index of their entry in `configs`, in the order of the entries.
+// Entries are numbered from 1, so that several entries of the same crypto-currency can be told apart
+func describeEntryErrors(configs []*walletbalance.Config, errs map[int]error) []string {
+	indices := make([]int, 0, len(errs))
+	for index := range errs {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	descriptions := make([]string, len(indices))
+	for position, index := range indices {
+		descriptions[position] = fmt.Sprintf("%s (entry %d): %s", configs[index].Label(), index+1, errs[index])
+	}
+
+	return descriptions
+}
+
+// describeError formats a fetch error with its classification and the provider it came from
+func describeError(err error) string {
+	description := err.Error()
+
+	// The errors of a query split into address batches all come from the same provider
+	providerErr := err
+	var batchErr *fetchers.BatchError
+	if errors.As(err, &batchErr) && len(batchErr.Errors) > 0 {
+		providerErr = batchErr.Errors[0]
+	}
+
+	var fetchErr *fetchers.Error
+	if errors.As(providerErr, &fetchErr) && fetchErr.Provider != "" {
+		description = fmt.Sprintf("%s: %s", fetchErr.Provider, description)
+	}
+	if kind := fetchers.ErrorKindOf(err); kind != fetchers.UnknownError {
+		description = fmt.Sprintf("%s (%s)", kind, description)
+	}
+
+	return description
+}
+
+func describeProfitAndLoss(profitAndLoss, returnPercentage float64) string {
+	pnlColor := color.New(color.FgHiGreen).SprintFunc()
+	if profitAndLoss < 0 {
+		pnlColor = color.New(color.FgHiRed).SprintFunc()
+	}
+
+	return pnlColor(fmt.Sprintf("%+.2f$ (%+.1f%%)", profitAndLoss, returnPercentage))
+}
+
+func describeManualHolding(report *walletbalance.CryptoCurrencyBalanceReport) string {
+	description := "manual entry"
+	if !report.AsOf.IsZero() {
+		description += fmt.Sprintf(" as of %s", report.AsOf.Format(walletbalance.DateLayout))
+	}
+	if report.Note != "" {
+		description += fmt.Sprintf(": %s", report.Note)
+	}
+
+	return description
+}
+
+func describeExchangeRateQuotes(report *walletbalance.CryptoCurrencyBalanceReport) string {
+	quoteDescriptions := make([]string, len(report.ExchangeRateQuotes))
+	for index, quote := range report.ExchangeRateQuotes {
+		switch {
+		case quote.Err != nil:
+			quoteDescriptions[index] = fmt.Sprintf("%s failed", quote.Source)
+		case quote.Outlier:
+			quoteDescriptions[index] = fmt.Sprintf("%s %.2f$ (outlier)", quote.Source, quote.Rate)
+		default:
+			quoteDescriptions[index] = fmt.Sprintf("%s %.2f$", quote.Source, quote.Rate)
 		}
 	}
-	fmt.Println("------------------------------------------")
-	fmt.Fprintf(color.Output, "USD balance: %s\n", usdColor(fmt.Sprintf("%.2f$", totalUsdBalance)))
+
+	return fmt.Sprintf("price quotes: %s; spread %.1f%%", strings.Join(quoteDescriptions, ", "), report.ExchangeRateSpread*100)
 }
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PombeirP/wallet-balance/fakeproviders"
+	"github.com/PombeirP/wallet-balance/walletbalance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLogger(t *testing.T) {
+	cases := []struct {
+		level          string
+		format         string
+		expectedErr    string
+		expectedOutput string
+	}{
+		{"debug", "text", "", "level=DEBUG msg=detail"},
+		{"warn", "text", "", "level=WARN msg=problem"},
+		{"info", "json", "", `"level":"WARN","msg":"problem"`},
+		{"verbose", "text", "invalid log level verbose, expected debug, info, warn or error", ""},
+		{"info", "xml", "invalid log format xml, expected text or json", ""},
+	}
+
+	for _, testCase := range cases {
+		var output bytes.Buffer
+		logger, err := newLogger(&output, testCase.level, testCase.format)
+		if testCase.expectedErr != "" {
+			require.EqualError(t, err, testCase.expectedErr)
+			continue
+		}
+		require.NoError(t, err)
+
+		logger.Debug("detail")
+		logger.Warn("problem")
+		require.Contains(t, output.String(), testCase.expectedOutput, testCase.level)
+		if testCase.level == "warn" {
+			require.NotContains(t, output.String(), "detail")
+		}
+	}
+}
+
+// writeTestConfig writes `config` to a config.json file in a temporary directory and returns its path
+func writeTestConfig(t *testing.T, config string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(config), 0644))
+
+	return path
+}
+
+// runAgainst runs the balances command with the configuration `config`, in which {blockchain.info}, {etherscan} and {cryptoid} stand for the base URLs of `server`
+func runAgainst(t *testing.T, server *fakeproviders.Server, config string) string {
+	config = strings.NewReplacer(
+		"{blockchain.info}", server.BaseURL(fakeproviders.BlockchainInfo),
+		"{etherscan}", server.BaseURL(fakeproviders.Etherscan),
+		"{cryptoid}", server.BaseURL(fakeproviders.Cryptoid),
+	).Replace(config)
+
+	var output bytes.Buffer
+	require.Equal(t, 0, run([]string{"--config", writeTestConfig(t, config), "--timeout", "30s"}, &output))
+
+	return output.String()
+}
+
+const integrationConfig = `[
+	{"symbol": "BTC", "addresses": ["1A", "1B"], "options": {"base_url": "{blockchain.info}"}},
+	{"symbol": "ETH", "addresses": ["0xa", "0xb"], "api_key": "etherkey", "options": {"base_url": "{etherscan}"}},
+	{"symbol": "LTC", "addresses": ["La", "Lb"], "api_key": "ltckey", "options": {"base_url": "{cryptoid}"}}
+]`
+
+func TestRunBalances(t *testing.T) {
+	server := fakeproviders.NewServer()
+	defer server.Close()
+
+	server.SetBalance(fakeproviders.BlockchainInfo, "1A", 0.5)
+	server.SetBalance(fakeproviders.BlockchainInfo, "1B", 1)
+	server.SetPrice(fakeproviders.BlockchainInfo, 50000)
+	server.SetBalance(fakeproviders.Etherscan, "0xa", 2)
+	server.SetBalance(fakeproviders.Etherscan, "0xb", 0.5)
+	server.SetPrice(fakeproviders.Etherscan, 2000)
+	server.RequireAPIKey(fakeproviders.Etherscan, "etherkey")
+	server.SetBalance(fakeproviders.Cryptoid, "La", 10)
+	server.SetBalance(fakeproviders.Cryptoid, "Lb", 0.25)
+	server.SetPrice(fakeproviders.Cryptoid, 80)
+	server.RequireAPIKey(fakeproviders.Cryptoid, "ltckey")
+
+	// Rate limits which clear up are retried transparently
+	server.RateLimit(fakeproviders.BlockchainInfo, 1)
+
+	output := runAgainst(t, server, integrationConfig)
+
+	require.Equal(t, `Fetching balances...
+BTC balance:   1.500000 BTC (in USD: 75000.00$, 1BTC = 50000.00$)
+ETH balance:   2.500000 ETH (in USD: 5000.00$, 1ETH = 2000.00$)
+LTC balance:  10.250000 LTC (in USD:  820.00$, 1LTC = 80.00$)
+------------------------------------------
+USD balance: 80820.00$
+`, output)
+	require.Equal(t, 3, server.Requests(fakeproviders.BlockchainInfo))
+}
+
+func TestRunBalancesWithFailingProviders(t *testing.T) {
+	server := fakeproviders.NewServer()
+	defer server.Close()
+
+	server.SetBalance(fakeproviders.BlockchainInfo, "1A", 0.5)
+	server.SetPrice(fakeproviders.BlockchainInfo, 50000)
+	server.SetBalance(fakeproviders.Etherscan, "0xa", 2)
+	server.SetBalance(fakeproviders.Etherscan, "0xb", 0.5)
+	server.RequireAPIKey(fakeproviders.Etherscan, "otherkey")
+	server.SetUnavailable(fakeproviders.Cryptoid, true)
+
+	output := runAgainst(t, server, integrationConfig)
+
+	require.Equal(t, `Fetching balances...
+BTC: invalid address (blockchain.info: Checksum does not validate: invalid Bitcoin address)
+ETH balance:   2.500000 ETH (in USD: unknown)
+    exchange rate: unauthorized (etherscan: Invalid API Key)
+LTC: provider unavailable (cryptoid: 2 of 2 address batches failed: Service Unavailable; Service Unavailable)
+------------------------------------------
+USD balance: 0.00$ (excluding unpriced holdings: BTC, ETH, LTC)
+`, output)
+	// Each of the requests to the unavailable provider (one per address and one for the exchange rate) was retried twice
+	require.Equal(t, 9, server.Requests(fakeproviders.Cryptoid))
+}
+
+func TestRunBalancesRateLimited(t *testing.T) {
+	server := fakeproviders.NewServer()
+	defer server.Close()
+
+	server.SetBalance(fakeproviders.Etherscan, "0xa", 2)
+	server.SetPrice(fakeproviders.Etherscan, 2000)
+	server.RateLimit(fakeproviders.Etherscan, 100)
+
+	output := runAgainst(t, server, `[{"symbol": "ETH", "addresses": ["0xa"], "options": {"base_url": "{etherscan}"}}]`)
+
+	require.Equal(t, `Fetching balances...
+ETH: rate limited (etherscan: Max rate limit reached)
+------------------------------------------
+USD balance: 0.00$ (excluding unpriced holdings: ETH)
+`, output)
+}
+
+func TestRunBalancesWithManualHolding(t *testing.T) {
+	server := fakeproviders.NewServer()
+	defer server.Close()
+
+	server.SetPrice(fakeproviders.BlockchainInfo, 50000)
+	server.SetBalance(fakeproviders.Etherscan, "0xa", 2)
+	server.SetPrice(fakeproviders.Etherscan, 2000)
+
+	output := runAgainst(t, server, `[
+		{"symbol": "BTC", "balance": 0.5, "note": "hardware wallet", "as_of": "2017-12-01", "options": {"base_url": "{blockchain.info}"}},
+		{"symbol": "ETH", "addresses": ["0xa"], "options": {"base_url": "{etherscan}"}}
+	]`)
+
+	require.Equal(t, `Fetching balances...
+BTC balance:   0.500000 BTC (in USD: 25000.00$, 1BTC = 50000.00$)
+    manual entry as of 2017-12-01: hardware wallet
+ETH balance:   2.000000 ETH (in USD: 4000.00$, 1ETH = 2000.00$)
+------------------------------------------
+USD balance: 29000.00$
+`, output)
+	// Only the exchange rate of the manual entry is fetched
+	require.Equal(t, 1, server.Requests(fakeproviders.BlockchainInfo))
+}
+
+func TestRunSubcommandExitCodes(t *testing.T) {
+	missingConfig := filepath.Join(t.TempDir(), "missing.json")
+	cases := []struct {
+		name             string
+		args             []string
+		expectedExitCode int
+	}{
+		{"transactions with a missing config", []string{"--config", missingConfig, "transactions"}, 1},
+		{"transactions with an unknown flag", []string{"--config", missingConfig, "transactions", "--unknown"}, 2},
+		{"tax-report with a missing config", []string{"--config", missingConfig, "tax-report"}, 1},
+		{"tax-report with an unknown method", []string{"--config", missingConfig, "tax-report", "--method", "hifo"}, 2},
+		{"tax-report help", []string{"tax-report", "--help"}, 0},
+	}
+
+	for _, testCase := range cases {
+		var output bytes.Buffer
+		require.Equal(t, testCase.expectedExitCode, run(testCase.args, &output), testCase.name)
+	}
+}
+
+func TestDescribeEntryErrors(t *testing.T) {
+	configs := []*walletbalance.Config{
+		{Symbol: walletbalance.BTC},
+		{Symbol: walletbalance.ETH},
+		{Symbol: walletbalance.BTC},
+		{Symbol: walletbalance.ETH, ProviderOptions: json.RawMessage(`{"chain": "arbitrum"}`)},
+	}
+	errs := map[int]error{3: errors.New("timed out"), 2: errors.New("rate limited"), 0: errors.New("invalid address")}
+
+	require.Equal(t, []string{"BTC (entry 1): invalid address", "BTC (entry 3): rate limited", "ETH (arbitrum) (entry 4): timed out"}, describeEntryErrors(configs, errs))
+}
@@ -0,0 +1,199 @@
+package walletbalance
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/PombeirP/wallet-balance/lots"
+)
+
+// defaultConcurrency is the number of config entries a Client fetches at once, unless set with WithConcurrency
+const defaultConcurrency = 3
+
+// Client fetches reports of the configured crypto-currency holdings from their providers. Its zero value is not usable, create one with NewClient
+type Client struct {
+	newCreator  func(ctx context.Context) CryptoCurrencyInfoFetcherCreator
+	concurrency int
+	logger      *slog.Logger
+}
+
+// Option configures a Client created by NewClient
+type Option func(*Client)
+
+// WithHTTPClient makes the Client fetch information from the providers over `client`, instead of an http.Client with a 10 second timeout.
+// Requests are cancelled along with the context of the fetch if `client` implements fetchers.HTTPDoer
+func WithHTTPClient(client fetchers.HTTPClient) Option {
+	return func(c *Client) {
+		// Exchange rates are fetched in batches shared by the fetchers of a creator, so each fetch uses a creator of its own
+		c.newCreator = func(ctx context.Context) CryptoCurrencyInfoFetcherCreator {
+			return NewCryptoCurrencyInfoHTTPFetcherCreator(fetchers.NewContextHTTPClient(ctx, client))
+		}
+	}
+}
+
+// WithFetcherCreator makes the Client create the fetchers of the config entries with a creator returned by `newCreator`, e.g. to fetch information from sources
+// other than the registered providers. `newCreator` is called on every fetch, so that creators batching exchange rates don't serve the prices of an earlier fetch
+func WithFetcherCreator(newCreator func() CryptoCurrencyInfoFetcherCreator) Option {
+	return func(c *Client) {
+		c.newCreator = func(ctx context.Context) CryptoCurrencyInfoFetcherCreator {
+			return newCreator()
+		}
+	}
+}
+
+// WithConcurrency sets the maximum number of config entries fetched at once. Defaults to 3
+func WithConcurrency(concurrency int) Option {
+	return func(c *Client) {
+		c.concurrency = concurrency
+	}
+}
+
+// WithLogger sets the logger the Client reports its progress and the errors encountered to. Defaults to slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// NewClient creates a Client configured by `options`
+func NewClient(options ...Option) *Client {
+	client := &Client{concurrency: defaultConcurrency}
+	WithHTTPClient(&http.Client{Timeout: 10 * time.Second})(client)
+	for _, option := range options {
+		option(client)
+	}
+	if client.logger == nil {
+		client.logger = slog.Default()
+	}
+
+	return client
+}
+
+// FetchReports fetches the current balance report of each config entry.
+// A report is returned for every entry, in the order of `configs`: entries which can't be fetched, or whose fetch doesn't complete before `ctx` is done,
+// are reported with an error. The HTTP requests still in flight when `ctx` is done are cancelled
+func (client *Client) FetchReports(ctx context.Context, configs []*Config) []*CryptoCurrencyBalanceReport {
+	return client.fetchBalanceReports(ctx, configs, time.Time{})
+}
+
+// FetchReportsAt fetches the balance report of each config entry as of `at`, valued with historical prices. See FetchReports
+func (client *Client) FetchReportsAt(ctx context.Context, configs []*Config, at time.Time) []*CryptoCurrencyBalanceReport {
+	historicalConfigs := make([]*Config, len(configs))
+	for index, config := range configs {
+		historicalConfigs[index] = withHistoricalPriceProvider(config)
+	}
+
+	return client.fetchBalanceReports(ctx, historicalConfigs, at)
+}
+
+// TrackCostBasis sets the cost basis of each report fetched by FetchReports from the transaction history of its holding,
+// or its CostBasisError if the history can't be fetched
+func (client *Client) TrackCostBasis(ctx context.Context, reports []*CryptoCurrencyBalanceReport) {
+	trackCostBasis(reports, client.newCreator(ctx))
+}
+
+// FetchTransactions fetches the transactions of all config entries, most recent first, along with the errors encountered keyed by the index of their entry in `configs`
+func (client *Client) FetchTransactions(ctx context.Context, configs []*Config) ([]*SymbolTransaction, map[int]error) {
+	return fetchTransactions(configs, client.newCreator(ctx))
+}
+
+// FetchDisposals replays the history of every config entry with the lot matching `method`, or the cost_basis.method of each entry if empty,
+// and returns the disposals that happened in [start, end) in chronological order, along with the errors encountered keyed by the index of their entry in `configs`
+func (client *Client) FetchDisposals(ctx context.Context, configs []*Config, method lots.Method, start, end time.Time) ([]*TaxableDisposal, map[int]error) {
+	return fetchDisposals(configs, client.newCreator(ctx), method, start, end)
+}
+
+// fetchBalanceReports fetches a report for each config entry, as of `asOf` or now if `asOf` is the zero time, with at most `client.concurrency` entries fetched at once
+func (client *Client) fetchBalanceReports(ctx context.Context, currenciesConfig []*Config, asOf time.Time) []*CryptoCurrencyBalanceReport {
+	type job struct {
+		index       int
+		config      *Config
+		infoFetcher fetchers.CryptoCurrencyInfoFetcher
+		err         error
+	}
+
+	// Create all fetchers up-front, so that exchange rates can be fetched in batches. Their requests are cancelled once `ctx` is done
+	currencyInfoFetcherCreator := client.newCreator(ctx)
+	pendingJobs := make([]*job, len(currenciesConfig))
+	for index, currencyConfig := range currenciesConfig {
+		infoFetcher, err := currencyInfoFetcherCreator.Create(currencyConfig)
+		pendingJobs[index] = &job{index, currencyConfig, infoFetcher, err}
+	}
+
+	reports := make([]*CryptoCurrencyBalanceReport, len(currenciesConfig))
+	jobs := make(chan *job)
+
+	// Define worker. Each job is fetched to completion before the next one is taken, so that no more than `workerCount` entries are fetched at once
+	worker := func(jobs <-chan *job, workersDone *sync.WaitGroup) {
+		defer workersDone.Done()
+
+		for j := range jobs {
+			if j.err != nil {
+				reports[j.index] = newFailedCryptoCurrencyBalanceReport(j.config, j.err)
+			} else {
+				started := time.Now()
+				client.logger.Debug("fetching entry", "symbol", j.config.Symbol, "provider", j.config.providerName())
+				reports[j.index] = fetchBalanceReport(ctx, j.config, j.infoFetcher, asOf)
+				client.logger.Debug("fetched entry", "symbol", j.config.Symbol, "provider", j.config.providerName(), "latency", time.Since(started))
+			}
+			client.logReportErrors(reports[j.index])
+		}
+	}
+
+	// Start up some workers
+	workerCount := client.concurrency
+	if workerCount < 1 {
+		workerCount = 1
+	}
+	workersDone := sync.WaitGroup{}
+	workersDone.Add(workerCount)
+	for index := 0; index < workerCount; index++ {
+		go worker(jobs, &workersDone)
+	}
+
+	// Wait for all jobs to complete
+	for _, pendingJob := range pendingJobs {
+		jobs <- pendingJob
+	}
+	close(jobs)
+	workersDone.Wait()
+
+	return reports
+}
+
+// logReportErrors logs the errors of a report with their classification
+func (client *Client) logReportErrors(report *CryptoCurrencyBalanceReport) {
+	if report.BalanceError != nil {
+		client.logger.Warn("balance not fetched", "symbol", report.Symbol, "kind", fetchers.ErrorKindOf(report.BalanceError).String(), "error", report.BalanceError)
+	}
+	if report.ExchangeRateError != nil && report.ExchangeRateError != report.BalanceError {
+		client.logger.Warn("exchange rate not fetched", "symbol", report.Symbol, "kind", fetchers.ErrorKindOf(report.ExchangeRateError).String(), "error", report.ExchangeRateError)
+	}
+}
+
+// fetchBalanceReport fetches the report of a config entry, giving up with an error report once `ctx` is done
+func fetchBalanceReport(ctx context.Context, config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, asOf time.Time) *CryptoCurrencyBalanceReport {
+	if ctx.Err() != nil {
+		return newFailedCryptoCurrencyBalanceReport(config, fmt.Errorf("not fetched: %w", ctx.Err()))
+	}
+
+	// The requests of an abandoned fetch are cancelled along with `ctx`, and its report is discarded
+	done := make(chan *CryptoCurrencyBalanceReport, 1)
+	if asOf.IsZero() {
+		go FetchInfoForCryptoCurrency(config, infoFetcher, done)
+	} else {
+		go FetchHistoricalInfoForCryptoCurrency(config, infoFetcher, asOf, done)
+	}
+
+	select {
+	case report := <-done:
+		return report
+	case <-ctx.Done():
+		return newFailedCryptoCurrencyBalanceReport(config, fmt.Errorf("fetch abandoned: %w", ctx.Err()))
+	}
+}
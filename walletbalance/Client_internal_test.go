@@ -0,0 +1,199 @@
+package walletbalance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInfoFetcher reports a fixed balance and exchange rate after `delay`, or once `release` is closed if set
+type fakeInfoFetcher struct {
+	balance      float64
+	exchangeRate float64
+	delay        time.Duration
+	release      chan struct{}
+
+	running    *int32
+	maxRunning *int32
+}
+
+func (fetcher *fakeInfoFetcher) wait() {
+	if fetcher.running != nil {
+		running := atomic.AddInt32(fetcher.running, 1)
+		defer atomic.AddInt32(fetcher.running, -1)
+		for {
+			maxRunning := atomic.LoadInt32(fetcher.maxRunning)
+			if running <= maxRunning || atomic.CompareAndSwapInt32(fetcher.maxRunning, maxRunning, running) {
+				break
+			}
+		}
+	}
+
+	if fetcher.release != nil {
+		<-fetcher.release
+	}
+	time.Sleep(fetcher.delay)
+}
+
+func (fetcher *fakeInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
+	fetcher.wait()
+	*balance, *err = fetcher.balance, nil
+	done.Done()
+}
+
+func (fetcher *fakeInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate, *err = fetcher.exchangeRate, nil
+	done.Done()
+}
+
+// fakeInfoFetcherCreator hands out the fake fetcher registered for each symbol, failing for unknown symbols
+type fakeInfoFetcherCreator map[CryptoCurrencyTickerSymbol]*fakeInfoFetcher
+
+func (creator fakeInfoFetcherCreator) newCreator() CryptoCurrencyInfoFetcherCreator {
+	return creator
+}
+
+func (creator fakeInfoFetcherCreator) Create(config *Config) (fetchers.CryptoCurrencyInfoFetcher, error) {
+	fetcher, ok := creator[config.Symbol]
+	if !ok {
+		return nil, fmt.Errorf("unknown crypto-currency %s, please specify a provider", config.Symbol)
+	}
+
+	return fetcher, nil
+}
+
+func TestClientFetchReportsReportsEveryEntry(t *testing.T) {
+	creator := fakeInfoFetcherCreator{
+		BTC: {balance: 1, exchangeRate: 10000},
+		LTC: {balance: 2, exchangeRate: 100},
+	}
+	configs := []*Config{{Symbol: BTC}, {Symbol: "XYZ"}, {Symbol: LTC}}
+
+	reports := NewClient(WithFetcherCreator(creator.newCreator), WithConcurrency(2)).FetchReports(context.Background(), configs)
+
+	require.Len(t, reports, 3)
+	require.Equal(t, BTC, reports[0].Symbol)
+	require.True(t, reports[0].Priced())
+	require.Equal(t, 10000., reports[0].UsdBalance())
+
+	require.Equal(t, CryptoCurrencyTickerSymbol("XYZ"), reports[1].Symbol)
+	require.EqualError(t, reports[1].BalanceError, "unknown crypto-currency XYZ, please specify a provider")
+	require.False(t, reports[1].Priced())
+
+	require.Equal(t, LTC, reports[2].Symbol)
+	require.Equal(t, 200., reports[2].UsdBalance())
+}
+
+func TestClientFetchReportsCreatesFetchersOnEveryFetch(t *testing.T) {
+	creator := fakeInfoFetcherCreator{BTC: {balance: 1, exchangeRate: 10000}}
+	creatorCount := 0
+	client := NewClient(WithFetcherCreator(func() CryptoCurrencyInfoFetcherCreator {
+		creatorCount++
+		return creator
+	}))
+
+	client.FetchReports(context.Background(), []*Config{{Symbol: BTC}})
+	client.FetchReports(context.Background(), []*Config{{Symbol: BTC}})
+
+	require.Equal(t, 2, creatorCount)
+}
+
+func TestClientFetchReportsBoundsConcurrency(t *testing.T) {
+	var running, maxRunning int32
+	creator := fakeInfoFetcherCreator{}
+	var configs []*Config
+	for index := 0; index < 8; index++ {
+		symbol := CryptoCurrencyTickerSymbol(fmt.Sprintf("C%d", index))
+		creator[symbol] = &fakeInfoFetcher{balance: 1, exchangeRate: 1, delay: 20 * time.Millisecond, running: &running, maxRunning: &maxRunning}
+		configs = append(configs, &Config{Symbol: symbol})
+	}
+
+	reports := NewClient(WithFetcherCreator(creator.newCreator), WithConcurrency(3)).FetchReports(context.Background(), configs)
+
+	require.Len(t, reports, 8)
+	for _, report := range reports {
+		require.True(t, report.Priced(), string(report.Symbol))
+	}
+	require.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+}
+
+func TestClientFetchReportsDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	creator := fakeInfoFetcherCreator{
+		BTC: {balance: 1, exchangeRate: 10000},
+		ETH: {balance: 5, exchangeRate: 300, release: release},
+		LTC: {balance: 2, exchangeRate: 100, release: release},
+		UNO: {balance: 3, exchangeRate: 1},
+	}
+	configs := []*Config{{Symbol: BTC}, {Symbol: ETH}, {Symbol: LTC}, {Symbol: UNO}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	reports := NewClient(WithFetcherCreator(creator.newCreator), WithConcurrency(2)).FetchReports(ctx, configs)
+
+	require.Less(t, int64(time.Since(started)), int64(time.Second))
+	require.Len(t, reports, 4)
+	require.True(t, reports[0].Priced())
+	for _, report := range reports[1:] {
+		// The stuck entries are abandoned, and the one queued behind them is never started
+		require.False(t, report.Priced(), string(report.Symbol))
+		require.True(t, errors.Is(report.BalanceError, context.DeadlineExceeded), string(report.Symbol))
+	}
+}
+
+func TestClientFetchReportsCanceled(t *testing.T) {
+	creator := fakeInfoFetcherCreator{BTC: {balance: 1, exchangeRate: 10000}}
+	configs := []*Config{{Symbol: BTC}, {Symbol: BTC}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reports := NewClient(WithFetcherCreator(creator.newCreator), WithConcurrency(1)).FetchReports(ctx, configs)
+
+	require.Len(t, reports, 2)
+	for _, report := range reports {
+		require.True(t, errors.Is(report.BalanceError, context.Canceled))
+	}
+}
+
+func TestClientFetchReportsCancelsRequests(t *testing.T) {
+	canceled := make(chan struct{}, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		canceled <- struct{}{}
+	}))
+	defer func() {
+		// Unblock the handlers of requests which weren't cancelled, so that the server can be closed
+		server.CloseClientConnections()
+		server.Close()
+	}()
+
+	configs := []*Config{{Symbol: LTC, Addresses: []string{"a"}, Provider: fetchers.BlockchairProvider, ProviderOptions: json.RawMessage(`{"base_url": "` + server.URL + `"}`)}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	reports := NewClient(WithHTTPClient(&http.Client{})).FetchReports(ctx, configs)
+
+	require.Len(t, reports, 1)
+	require.True(t, errors.Is(reports[0].BalanceError, context.DeadlineExceeded))
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the requests of the abandoned fetch were not cancelled")
+	}
+}
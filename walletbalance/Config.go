@@ -0,0 +1,203 @@
+package walletbalance
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/address"
+	"github.com/PombeirP/wallet-balance/fetchers"
+)
+
+// DateLayout is the layout of the dates in the configuration file (YYYY-MM-DD)
+const DateLayout = "2006-01-02"
+
+// symbolAliases maps deprecated ticker symbols to the ones they were renamed to
+var symbolAliases = map[CryptoCurrencyTickerSymbol]CryptoCurrencyTickerSymbol{
+	"BCC": BCH,
+}
+
+// Date represents a calendar date specified in the configuration file in YYYY-MM-DD format
+type Date struct {
+	time.Time
+}
+
+// UnmarshalJSON parses a date in YYYY-MM-DD format
+func (date *Date) UnmarshalJSON(raw []byte) (err error) {
+	var value string
+	if err = json.Unmarshal(raw, &value); err != nil {
+		return
+	}
+
+	if value == "" {
+		date.Time = time.Time{}
+		return
+	}
+
+	date.Time, err = time.Parse(DateLayout, value)
+
+	return
+}
+
+// Config is an entry of the configuration file, describing the holdings of a crypto-currency and how to fetch their balance and exchange rate
+type Config struct {
+	Symbol        CryptoCurrencyTickerSymbol `json:"symbol,omitempty"`
+	Addresses     []string                   `json:"addresses,omitempty"`
+	APIKey        string                     `json:"api_key,omitempty"`
+	ManualBalance *float64                   `json:"balance,omitempty"`
+	Note          string                     `json:"note,omitempty"`
+	AsOf          Date                       `json:"as_of,omitempty"`
+
+	// MinConfirmations is the number of confirmations below which funds are reported as pending instead of being included in the balance.
+	// Pending funds are not tracked separately when zero
+	MinConfirmations int `json:"min_confirmations,omitempty"`
+
+	// Provider is the name of the registered fetcher provider to use (e.g. "cryptoid"). Defaults to a provider suited to Symbol
+	Provider        string          `json:"provider,omitempty"`
+	ProviderOptions json.RawMessage `json:"options,omitempty"`
+
+	// PriceProvider is the name of the registered price provider to fetch the exchange rate from (e.g. "coingecko").
+	// Defaults to the exchange rate reported by Provider
+	PriceProvider string `json:"price_provider,omitempty"`
+	// PriceID overrides the identifier of the crypto-currency used by PriceProvider (e.g. "bitcoin-cash")
+	PriceID string `json:"price_id,omitempty"`
+	// PriceProviders lists several price providers (including "explorer") whose median quote is used as the exchange rate. Takes precedence over PriceProvider
+	PriceProviders []string `json:"price_providers,omitempty"`
+	// MaxPriceDeviation is the relative deviation from the median beyond which a quote from PriceProviders is discarded. Defaults to 0.1
+	MaxPriceDeviation float64 `json:"max_price_deviation,omitempty"`
+
+	// CostBasis configures how the cost basis of the holdings is tracked
+	CostBasis *CostBasisConfig `json:"cost_basis,omitempty"`
+}
+
+// CostBasisConfig holds the cost basis tracking settings of a config entry
+type CostBasisConfig struct {
+	// Method is the lot matching method: "fifo" (default), "lifo" or "average"
+	Method string `json:"method,omitempty"`
+	// Prices overrides the unit price of specific transactions, keyed by transaction id
+	Prices map[string]float64 `json:"prices,omitempty"`
+	// Lots lists acquisitions which are not part of the on-chain transaction history (e.g. for manual holdings)
+	Lots []*LotConfig `json:"lots,omitempty"`
+}
+
+// LotConfig describes an acquisition lot specified in the configuration file
+type LotConfig struct {
+	Date   Date    `json:"date"`
+	Amount float64 `json:"amount"`
+	Price  float64 `json:"price"`
+}
+
+// IsManual returns true if the entry holds a fixed, manually-entered balance instead of a list of addresses
+func (config *Config) IsManual() bool {
+	return config.ManualBalance != nil
+}
+
+// priceProvider returns the name of the price provider of the entry, falling back to the default price provider of its provider
+func (config *Config) priceProvider() string {
+	if config.PriceProvider != "" {
+		return config.PriceProvider
+	}
+
+	return defaultPriceProviders[config.Provider]
+}
+
+// providerName returns the name of the provider fetching the balance of the entry, falling back to the default provider of its symbol
+func (config *Config) providerName() string {
+	if config.Provider != "" {
+		return config.Provider
+	}
+
+	return defaultProviders[config.Symbol]
+}
+
+// Label returns the name the entry is reported under: its ticker symbol, followed by the chain when it is held on another chain than
+// the symbol's default one (e.g. "ETH (arbitrum)"), so that entries of the same crypto-currency on different chains can be told apart
+func (config *Config) Label() string {
+	if config.providerName() == fetchers.EtherscanProvider {
+		if chain := fetchers.EtherscanAlternativeChain(string(config.Symbol), config.ProviderOptions); chain != "" {
+			return fmt.Sprintf("%s (%s)", config.Symbol, chain)
+		}
+	}
+
+	return string(config.Symbol)
+}
+
+// exchangeRateSourceName returns a description of where the exchange rate of the entry is fetched from
+func (config *Config) exchangeRateSourceName() string {
+	if len(config.PriceProviders) > 0 {
+		return strings.Join(config.PriceProviders, ", ")
+	}
+	if priceProvider := config.priceProvider(); priceProvider != "" && priceProvider != explorerPriceProvider {
+		return priceProvider
+	}
+
+	return config.providerName()
+}
+
+// LoadConfigFromJSONFile loads the configuration entries listed in the JSON file at `path`
+func LoadConfigFromJSONFile(path string) ([]*Config, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, info.Size())
+	_, err = io.ReadFull(file, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadConfigFromJSON(raw)
+}
+
+// LoadConfigFromJSON loads the configuration entries listed in `rawJSON`, resolving deprecated symbols and validating addresses
+func LoadConfigFromJSON(rawJSON []byte) (currencies []*Config, err error) {
+	if err = json.Unmarshal(rawJSON, &currencies); err != nil {
+		return
+	}
+
+	for _, currency := range currencies {
+		if err = currency.normalize(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+// normalize resolves deprecated symbol aliases and validates the addresses of the entry, converting them to their canonical form
+func (config *Config) normalize() error {
+	if symbol, ok := symbolAliases[config.Symbol]; ok {
+		config.Symbol = symbol
+	}
+
+	if config.Symbol == BCH {
+		// Bitcoin Cash addresses may be given in legacy or CashAddr format, and are stored as CashAddr
+		for index, legacyOrCashAddr := range config.Addresses {
+			if address.IsExtendedPublicKey(legacyOrCashAddr) {
+				if provider := config.providerName(); provider != fetchers.BlockbookProvider {
+					return fmt.Errorf("extended public keys are only supported by the %s provider, not %s", fetchers.BlockbookProvider, provider)
+				}
+				continue
+			}
+
+			cashAddr, err := address.ToCashAddr(legacyOrCashAddr)
+			if err != nil {
+				return fmt.Errorf("invalid %s address %s: %s", config.Symbol, legacyOrCashAddr, err)
+			}
+			config.Addresses[index] = cashAddr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package walletbalance
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFromJSON(t *testing.T) {
+	manualBalance := 1.5
+
+	cases := []struct {
+		name                 string
+		specifiedJSON        string
+		expectedErrorMessage string
+		expected             []Config
+	}{
+		{"case #1", `[{"symbol": "BTC", "addresses": ["a"]},{"symbol": "DASH","addresses": ["b","c"],"api_key": "apikey1"},{"symbol": "ETH","addresses": ["d"],"api_key": "apikey2"}]`,
+			"",
+			[]Config{
+				{Symbol: BTC, Addresses: []string{"a"}},
+				{Symbol: DASH, Addresses: []string{"b", "c"}, APIKey: "apikey1"},
+				{Symbol: ETH, Addresses: []string{"d"}, APIKey: "apikey2"},
+			},
+		},
+		{"case #2", `[{"symbol": "UNO", "addresses": ["asdkfhjkadfghds"]}]`,
+			"",
+			[]Config{
+				{Symbol: UNO, Addresses: []string{"asdkfhjkadfghds"}},
+			},
+		},
+		{"case #3 (invalid JSON)", `[{"symbol": "UNO", "addresses": ["asdkfhjkadfghds",]}]`,
+			"invalid character ']' looking for beginning of value",
+			[]Config{},
+		},
+		{"case #4 (manual holding)", `[{"symbol": "BTC", "balance": 1.5, "note": "Kraken", "as_of": "2017-12-31"}]`,
+			"",
+			[]Config{
+				{Symbol: BTC, ManualBalance: &manualBalance, Note: "Kraken", AsOf: Date{time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC)}},
+			},
+		},
+		{"case #5 (explicit provider)", `[{"symbol": "LTC", "addresses": ["e"], "provider": "cryptoid", "options": {"currency": "ltc"}}]`,
+			"",
+			[]Config{
+				{Symbol: LTC, Addresses: []string{"e"}, Provider: "cryptoid", ProviderOptions: json.RawMessage(`{"currency": "ltc"}`)},
+			},
+		},
+		{"case #6 (invalid as-of date)", `[{"symbol": "BTC", "balance": 1.5, "as_of": "31/12/2017"}]`,
+			`parsing time "31/12/2017" as "2006-01-02": cannot parse "31/12/2017" as "2006"`,
+			[]Config{},
+		},
+		{"case #7 (BCC alias and BCH addresses)", `[{"symbol": "BCC", "addresses": ["1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy", "xpub6CUGRU"], "provider": "blockbook"}]`,
+			"",
+			[]Config{
+				{Symbol: BCH, Addresses: []string{"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy", "xpub6CUGRU"}, Provider: "blockbook"},
+			},
+		},
+		{"case #8 (invalid BCH address)", `[{"symbol": "BCH", "addresses": ["bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuz"]}]`,
+			"invalid BCH address bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuz: invalid checksum",
+			[]Config{},
+		},
+		{"case #9 (BCH extended public key without blockbook)", `[{"symbol": "BCH", "addresses": ["xpub6CUGRU"]}]`,
+			"extended public keys are only supported by the blockbook provider, not blockchair",
+			[]Config{},
+		},
+	}
+
+	for _, testCase := range cases {
+		testCaseName := fmt.Sprintf("Test case %s", testCase.name)
+		config, err := LoadConfigFromJSON([]byte(testCase.specifiedJSON))
+
+		if testCase.expectedErrorMessage != "" {
+			require.Error(t, err, testCaseName)
+			require.Equal(t, testCase.expectedErrorMessage, err.Error(), testCaseName)
+		} else {
+			require.NotNil(t, config, testCaseName)
+			require.Len(t, config, len(testCase.expected), "%s: expected %d crypto-currencies", testCaseName, len(testCase.expected))
+		}
+
+		for idx, expected := range testCase.expected {
+			require.Equal(t, expected.Symbol, config[idx].Symbol, testCaseName)
+			require.Equal(t, expected.APIKey, config[idx].APIKey, testCaseName)
+			require.EqualValues(t, expected.Addresses, config[idx].Addresses, testCaseName)
+			require.Equal(t, expected.ManualBalance, config[idx].ManualBalance, testCaseName)
+			require.Equal(t, expected.Note, config[idx].Note, testCaseName)
+			require.Equal(t, expected.Provider, config[idx].Provider, testCaseName)
+			require.Equal(t, string(expected.ProviderOptions), string(config[idx].ProviderOptions), testCaseName)
+			require.True(t, expected.AsOf.Equal(config[idx].AsOf.Time), testCaseName)
+		}
+	}
+}
+
+func TestConfigLabel(t *testing.T) {
+	cases := []struct {
+		name          string
+		specifiedJSON string
+		expected      string
+	}{
+		{"default chain", `{"symbol": "ETH", "addresses": ["a"]}`, "ETH"},
+		{"explicit default chain", `{"symbol": "ETH", "addresses": ["a"], "options": {"chain": "ethereum"}}`, "ETH"},
+		{"arbitrum", `{"symbol": "ETH", "addresses": ["a"], "options": {"chain": "arbitrum"}}`, "ETH (arbitrum)"},
+		{"optimism with explicit provider", `{"symbol": "ETH", "addresses": ["a"], "provider": "etherscan", "options": {"chain": "optimism"}}`, "ETH (optimism)"},
+		{"chain of another provider", `{"symbol": "DOGE", "addresses": ["a"], "provider": "blockchair", "options": {"chain": "dogecoin"}}`, "DOGE"},
+		{"manual holding", `{"symbol": "BTC", "balance": 1.5}`, "BTC"},
+	}
+
+	for _, testCase := range cases {
+		var config Config
+		require.NoError(t, json.Unmarshal([]byte(testCase.specifiedJSON), &config), testCase.name)
+		require.Equal(t, testCase.expected, config.Label(), testCase.name)
+	}
+}
@@ -0,0 +1,204 @@
+package walletbalance
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/PombeirP/wallet-balance/lots"
+)
+
+// withHistoricalPriceProvider returns `config`, or a copy of it using CoinGecko prices if it relies on explorer exchange rates,
+// since explorers only report current exchange rates
+func withHistoricalPriceProvider(config *Config) *Config {
+	if config.PriceProvider != "" && config.PriceProvider != explorerPriceProvider || len(config.PriceProviders) > 0 {
+		return config
+	}
+
+	historicalConfig := *config
+	historicalConfig.PriceProvider = fetchers.CoinGeckoPriceProviderName
+
+	return &historicalConfig
+}
+
+// ledgerEvent is an acquisition (positive amount) or disposal (negative amount) replayed into a lots.Ledger
+type ledgerEvent struct {
+	at     time.Time
+	amount float64
+	// price is the unit price of the event, or zero if it must be looked up
+	price float64
+}
+
+// buildLedger replays the configured lots and the on-chain transaction history of a config entry into a lots.Ledger.
+// Transactions are valued at the daily exchange rate of their date, unless their price is overridden in the configuration.
+// Lots are matched using `method`, or the method of the config entry if `method` is empty.
+func buildLedger(config *Config, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator, method lots.Method) (*lots.Ledger, error) {
+	method, err := ledgerMethod(config, method)
+	if err != nil {
+		return nil, err
+	}
+
+	infoFetcher, err := currencyInfoFetcherCreator.Create(withHistoricalPriceProvider(config))
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := fetchTransactionHistory(config, infoFetcher)
+	if err != nil {
+		return nil, err
+	}
+
+	return replayLedger(config, infoFetcher, ledgerEvents(config, transactions), method)
+}
+
+// ledgerMethod returns `method`, or the lot matching method of the config entry if `method` is empty
+func ledgerMethod(config *Config, method lots.Method) (lots.Method, error) {
+	if method != "" {
+		return method, nil
+	}
+	if config.CostBasis == nil {
+		return lots.ParseMethod("")
+	}
+
+	return lots.ParseMethod(config.CostBasis.Method)
+}
+
+// fetchTransactionHistory retrieves the complete on-chain transaction history of a config entry with `infoFetcher`. Manual entries have none
+func fetchTransactionHistory(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher) (transactions []*fetchers.Transaction, err error) {
+	if config.IsManual() {
+		return nil, nil
+	}
+
+	// Every acquisition is needed to value the holdings, so the complete history is fetched rather than the most recent transactions
+	if !supportsTransactionHistory(infoFetcher) {
+		return nil, fmt.Errorf("the %s provider doesn't report the complete transaction history needed to track the cost basis", config.providerName())
+	}
+	historyFetcher := infoFetcher.(fetchers.CryptoCurrencyTransactionHistoryFetcher)
+
+	transactionsFetched := sync.WaitGroup{}
+	transactionsFetched.Add(1)
+	historyFetcher.FetchTransactionHistory(config.Addresses, config.APIKey, &transactions, &err, &transactionsFetched)
+	transactionsFetched.Wait()
+
+	return
+}
+
+// ledgerEvents returns the acquisitions and disposals of the lots configured for an entry and of its `transactions`,
+// priced with the overrides of the configuration
+func ledgerEvents(config *Config, transactions []*fetchers.Transaction) (events []*ledgerEvent) {
+	costBasis := config.CostBasis
+	if costBasis == nil {
+		costBasis = &CostBasisConfig{}
+	}
+
+	for _, lot := range costBasis.Lots {
+		events = append(events, &ledgerEvent{lot.Date.Time, lot.Amount, lot.Price})
+	}
+	for _, tx := range transactions {
+		events = append(events, &ledgerEvent{tx.Time, tx.NetAmount(), costBasis.Prices[tx.TxID]})
+	}
+
+	return
+}
+
+// replayLedger replays `events` in chronological order into a lots.Ledger matching lots with `method`.
+// Events without a price are valued at the daily exchange rate of their date, fetched with `infoFetcher`
+func replayLedger(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, events []*ledgerEvent, method lots.Method) (*lots.Ledger, error) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].at.Before(events[j].at)
+	})
+
+	dailyPrices := make(map[string]float64)
+	ledger := lots.NewLedger(method)
+	for _, event := range events {
+		price := event.price
+		if price == 0 {
+			var err error
+			if price, err = fetchDailyPrice(config, infoFetcher, event.at, dailyPrices); err != nil {
+				return nil, err
+			}
+		}
+
+		if event.amount >= 0 {
+			ledger.Acquire(event.at, event.amount, price)
+		} else {
+			ledger.Dispose(event.at, -event.amount, price)
+		}
+	}
+
+	return ledger, nil
+}
+
+// supportsTransactionHistory returns true if `infoFetcher` can retrieve the complete transaction history,
+// looking through composite fetchers which only forward the history of their balance fetcher
+func supportsTransactionHistory(infoFetcher fetchers.CryptoCurrencyInfoFetcher) bool {
+	if composite, ok := infoFetcher.(*fetchers.CompositeInfoFetcher); ok {
+		_, ok = composite.CryptoCurrencyBalanceFetcher.(fetchers.CryptoCurrencyTransactionHistoryFetcher)
+		return ok
+	}
+	_, ok := infoFetcher.(fetchers.CryptoCurrencyTransactionHistoryFetcher)
+
+	return ok
+}
+
+// fetchDailyPrice retrieves the USD exchange rate on the date of `at`, caching it in `dailyPrices`
+func fetchDailyPrice(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, at time.Time, dailyPrices map[string]float64) (price float64, err error) {
+	date := at.UTC().Format(DateLayout)
+	if price, ok := dailyPrices[date]; ok {
+		return price, nil
+	}
+
+	historicalFetcher, ok := infoFetcher.(fetchers.CryptoCurrencyHistoricalExchangeRateFetcher)
+	if !ok {
+		return 0, fmt.Errorf("historical exchange rates are not supported by %T", infoFetcher)
+	}
+
+	priceFetched := sync.WaitGroup{}
+	priceFetched.Add(1)
+	historicalFetcher.FetchExchangeRateAt(config.APIKey, "usd", at, &price, &err, &priceFetched)
+	priceFetched.Wait()
+
+	if err == nil {
+		dailyPrices[date] = price
+	}
+
+	return
+}
+
+// holdingsTolerance is the largest difference between the holdings replayed into a ledger and the balance of a report for which they are considered equal
+const holdingsTolerance = 1e-8
+
+// trackCostBasis computes the cost basis of each report concurrently. The cost basis is only reported if the holdings replayed from the history
+// match the balance of the report, since a missing acquisition or disposal would otherwise go unnoticed
+func trackCostBasis(reports []*CryptoCurrencyBalanceReport, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator) {
+	ledgersBuilt := sync.WaitGroup{}
+	for _, report := range reports {
+		if report == nil || report.Config == nil {
+			continue
+		}
+
+		ledgersBuilt.Add(1)
+		go func(report *CryptoCurrencyBalanceReport) {
+			defer ledgersBuilt.Done()
+
+			ledger, err := buildLedger(report.Config, currencyInfoFetcherCreator, "")
+			if err != nil {
+				report.CostBasisError = err
+				return
+			}
+
+			// Pending transactions are part of the history, but not of the balance when a minimum number of confirmations is required
+			balance := report.Balance + report.PendingIncoming - report.PendingOutgoing
+			if report.BalanceError == nil && math.Abs(ledger.Holdings()-balance) > holdingsTolerance {
+				report.CostBasisError = fmt.Errorf("the transaction history and lots account for %.8f %s, but the balance is %.8f %s", ledger.Holdings(), report.Symbol, balance, report.Symbol)
+				return
+			}
+
+			report.CostBasis = ledger.CostBasis()
+		}(report)
+	}
+	ledgersBuilt.Wait()
+}
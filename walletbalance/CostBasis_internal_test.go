@@ -0,0 +1,76 @@
+package walletbalance
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHistoryFetcher reports a fixed transaction history, valued at a fixed daily price
+type fakeHistoryFetcher struct {
+	fakeInfoFetcher
+	transactions []*fetchers.Transaction
+	price        float64
+}
+
+func (fetcher *fakeHistoryFetcher) FetchTransactionHistory(addresses []string, apiKey string, transactions *[]*fetchers.Transaction, err *error, done *sync.WaitGroup) {
+	*transactions, *err = fetcher.transactions, nil
+	done.Done()
+}
+
+func (fetcher *fakeHistoryFetcher) FetchExchangeRateAt(apiKey string, targetCurrency string, at time.Time, exchangeRate *float64, err *error, done *sync.WaitGroup) {
+	*exchangeRate, *err = fetcher.price, nil
+	done.Done()
+}
+
+// fakeHistoryFetcherCreator hands out the fake fetcher registered for each symbol
+type fakeHistoryFetcherCreator map[CryptoCurrencyTickerSymbol]*fakeHistoryFetcher
+
+func (creator fakeHistoryFetcherCreator) Create(config *Config) (fetchers.CryptoCurrencyInfoFetcher, error) {
+	return creator[config.Symbol], nil
+}
+
+func TestTrackCostBasisChecksHoldingsAgainstBalance(t *testing.T) {
+	received := func(txID string, amount float64) *fetchers.Transaction {
+		return &fetchers.Transaction{TxID: txID, Time: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), Movements: []*fetchers.AddressMovement{{Address: "a", In: amount}}}
+	}
+	creator := fakeHistoryFetcherCreator{BTC: {transactions: []*fetchers.Transaction{received("tx1", 1), received("tx2", 0.5)}, price: 1000}}
+
+	cases := []struct {
+		name              string
+		balance           float64
+		pendingIncoming   float64
+		expectedCostBasis float64
+		expectedErr       string
+	}{
+		{"history matches the balance", 1.5, 0, 1500, ""},
+		{"pending amounts are part of the history", 1, 0.5, 1500, ""},
+		{"history is missing transactions", 2.5, 0, 0, "the transaction history and lots account for 1.50000000 BTC, but the balance is 2.50000000 BTC"},
+	}
+
+	for _, testCase := range cases {
+		report := NewCryptoCurrencyBalanceReport(BTC, testCase.balance, 1000, nil, nil)
+		report.PendingIncoming = testCase.pendingIncoming
+		report.Config = &Config{Symbol: BTC, Addresses: []string{"a"}}
+
+		trackCostBasis([]*CryptoCurrencyBalanceReport{report}, creator)
+
+		if testCase.expectedErr != "" {
+			require.EqualError(t, report.CostBasisError, testCase.expectedErr, testCase.name)
+		} else {
+			require.NoError(t, report.CostBasisError, testCase.name)
+		}
+		require.InDelta(t, testCase.expectedCostBasis, report.CostBasis, 1e-9, testCase.name)
+	}
+}
+
+func TestBuildLedgerRejectsProvidersWithoutHistory(t *testing.T) {
+	creator := NewCryptoCurrencyInfoHTTPFetcherCreator(&http.Client{})
+
+	_, err := buildLedger(&Config{Symbol: LTC, Addresses: []string{"La"}}, creator, "")
+	require.EqualError(t, err, "the cryptoid provider doesn't report the complete transaction history needed to track the cost basis")
+}
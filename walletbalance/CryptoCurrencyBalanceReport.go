@@ -0,0 +1,181 @@
+package walletbalance
+
+import (
+	"sync"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+)
+
+// CryptoCurrencyTickerSymbol represents the ticker symbol for a crypto-currency
+type CryptoCurrencyTickerSymbol string
+
+// Ticker symbols of the crypto-currencies with a default provider
+const (
+	BTC   CryptoCurrencyTickerSymbol = "BTC"
+	ETH   CryptoCurrencyTickerSymbol = "ETH"
+	LTC   CryptoCurrencyTickerSymbol = "LTC"
+	DASH  CryptoCurrencyTickerSymbol = "DASH"
+	UNO   CryptoCurrencyTickerSymbol = "UNO"
+	BCH   CryptoCurrencyTickerSymbol = "BCH"
+	MATIC CryptoCurrencyTickerSymbol = "MATIC"
+	BNB   CryptoCurrencyTickerSymbol = "BNB"
+)
+
+// CryptoCurrencyBalanceReport provides functionality to check for the aggregate balance of crypto-currency addresses
+type CryptoCurrencyBalanceReport struct {
+	Symbol CryptoCurrencyTickerSymbol
+	// Label is the name the holding is reported under, which includes its chain when it isn't the default one of Symbol (e.g. "ETH (arbitrum)")
+	Label           string
+	UsdExchangeRate float64
+	Balance         float64
+
+	// BalanceError and ExchangeRateError are kept apart, so that a balance can still be reported when its exchange rate is missing
+	BalanceError      error
+	ExchangeRateError error
+
+	// PendingIncoming and PendingOutgoing hold the amounts below the configured minimum number of confirmations, which are excluded from Balance
+	PendingIncoming float64
+	PendingOutgoing float64
+
+	// Manual is true when the balance was entered manually in the configuration rather than fetched from a blockchain
+	Manual bool
+	Note   string
+	AsOf   time.Time
+
+	// ExchangeRateQuotes holds the individual quotes the exchange rate was derived from, when several price sources are configured
+	ExchangeRateQuotes []*fetchers.ExchangeRateQuote
+	// ExchangeRateSpread is the spread of ExchangeRateQuotes relative to their median
+	ExchangeRateSpread float64
+
+	// ValuedAt is the point in time the balance and exchange rate refer to
+	ValuedAt time.Time
+
+	// CostBasis is the USD cost of the holdings, when cost basis tracking is enabled
+	CostBasis      float64
+	CostBasisError error
+
+	// Config is the configuration entry the report was produced for. Nil if the entry could not be fetched at all
+	Config *Config
+}
+
+// Priced returns true if both the balance and its exchange rate are known, and hence the value of the holding in USD
+func (report *CryptoCurrencyBalanceReport) Priced() bool {
+	return report.BalanceError == nil && report.ExchangeRateError == nil
+}
+
+// UsdBalance returns the value of the balance in USD
+func (report *CryptoCurrencyBalanceReport) UsdBalance() float64 {
+	return report.Balance * report.UsdExchangeRate
+}
+
+// UnrealizedProfitAndLoss returns the USD gain (or loss, if negative) of the holdings over their cost basis
+func (report *CryptoCurrencyBalanceReport) UnrealizedProfitAndLoss() float64 {
+	return report.UsdBalance() - report.CostBasis
+}
+
+// ReturnPercentage returns the unrealized profit or loss as a percentage of the cost basis
+func (report *CryptoCurrencyBalanceReport) ReturnPercentage() float64 {
+	if report.CostBasis == 0 {
+		return 0
+	}
+
+	return report.UnrealizedProfitAndLoss() / report.CostBasis * 100
+}
+
+// NewCryptoCurrencyBalanceReport creates a crypto-currency balance report instance for the given crypto-currency
+func NewCryptoCurrencyBalanceReport(symbol CryptoCurrencyTickerSymbol, balance, exchangeRate float64, balanceErr, exchangeRateErr error) *CryptoCurrencyBalanceReport {
+	return &CryptoCurrencyBalanceReport{Symbol: symbol, Label: string(symbol), Balance: balance, UsdExchangeRate: exchangeRate, BalanceError: balanceErr, ExchangeRateError: exchangeRateErr}
+}
+
+// FetchInfoForCryptoCurrency retrieves the exchange rate and the aggregate balances for the provided addresses
+func FetchInfoForCryptoCurrency(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, done chan<- *CryptoCurrencyBalanceReport) {
+	infoFetched := sync.WaitGroup{}
+	infoFetched.Add(2)
+
+	var report *CryptoCurrencyBalanceReport
+	var balance, usdExchangeRate float64
+	var detailedBalance fetchers.DetailedBalance
+	var err1, err2 error
+
+	detailed := config.MinConfirmations > 0
+	if detailed {
+		detailedBalanceFetcher, ok := infoFetcher.(fetchers.CryptoCurrencyDetailedBalanceFetcher)
+		if !ok {
+			// Let the composite fetcher report that confirmed balances aren't supported
+			detailedBalanceFetcher = fetchers.NewCompositeInfoFetcher(infoFetcher, infoFetcher)
+		}
+		go detailedBalanceFetcher.FetchDetailedBalance(config.Addresses, config.APIKey, config.MinConfirmations, &detailedBalance, &err1, &infoFetched)
+	} else {
+		go infoFetcher.FetchBalance(config.Addresses, config.APIKey, &balance, &err1, &infoFetched)
+	}
+	go infoFetcher.FetchExchangeRate(config.APIKey, "usd", &usdExchangeRate, &err2, &infoFetched)
+
+	infoFetched.Wait()
+
+	if detailed {
+		balance = detailedBalance.Confirmed
+	}
+	report = newCryptoCurrencyBalanceReportForConfig(config, infoFetcher, balance, usdExchangeRate, err1, err2)
+	report.PendingIncoming, report.PendingOutgoing = detailedBalance.PendingIncoming, detailedBalance.PendingOutgoing
+	report.ValuedAt = time.Now()
+
+	done <- report
+}
+
+// FetchHistoricalInfoForCryptoCurrency retrieves the daily exchange rate on the date of `at` and the aggregate balances for the provided addresses at `at`
+func FetchHistoricalInfoForCryptoCurrency(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, at time.Time, done chan<- *CryptoCurrencyBalanceReport) {
+	historicalInfoFetcher, ok := infoFetcher.(fetchers.CryptoCurrencyHistoricalInfoFetcher)
+	if !ok {
+		// Let the composite fetcher report which of the balance or exchange rate lacks historical support
+		historicalInfoFetcher = fetchers.NewCompositeInfoFetcher(infoFetcher, infoFetcher)
+	}
+
+	infoFetched := sync.WaitGroup{}
+	infoFetched.Add(2)
+
+	var report *CryptoCurrencyBalanceReport
+	var balance, usdExchangeRate float64
+	var err1, err2 error
+
+	go historicalInfoFetcher.FetchBalanceAt(config.Addresses, config.APIKey, at, &balance, &err1, &infoFetched)
+	go historicalInfoFetcher.FetchExchangeRateAt(config.APIKey, "usd", at, &usdExchangeRate, &err2, &infoFetched)
+
+	infoFetched.Wait()
+
+	report = newCryptoCurrencyBalanceReportForConfig(config, infoFetcher, balance, usdExchangeRate, err1, err2)
+	report.ValuedAt = at
+
+	done <- report
+}
+
+// newFailedCryptoCurrencyBalanceReport creates a report for a config entry whose information couldn't be fetched at all
+func newFailedCryptoCurrencyBalanceReport(config *Config, err error) *CryptoCurrencyBalanceReport {
+	err = fetchers.AnnotateError(err, config.providerName(), string(config.Symbol))
+
+	report := NewCryptoCurrencyBalanceReport(config.Symbol, 0, 0, err, err)
+	report.Label = config.Label()
+	report.Manual = config.IsManual()
+	report.Note = config.Note
+	report.AsOf = config.AsOf.Time
+
+	return report
+}
+
+func newCryptoCurrencyBalanceReportForConfig(config *Config, infoFetcher fetchers.CryptoCurrencyInfoFetcher, balance, usdExchangeRate float64, balanceErr, exchangeRateErr error) *CryptoCurrencyBalanceReport {
+	// Record where each error came from, so that it can be reported with its classification
+	balanceErr = fetchers.AnnotateError(balanceErr, config.providerName(), string(config.Symbol))
+	exchangeRateErr = fetchers.AnnotateError(exchangeRateErr, config.exchangeRateSourceName(), string(config.Symbol))
+
+	report := NewCryptoCurrencyBalanceReport(config.Symbol, balance, usdExchangeRate, balanceErr, exchangeRateErr)
+	report.Label = config.Label()
+	report.Manual = config.IsManual()
+	report.Note = config.Note
+	report.AsOf = config.AsOf.Time
+	report.Config = config
+	if auditor, ok := infoFetcher.(fetchers.ExchangeRateAuditor); ok {
+		report.ExchangeRateQuotes, report.ExchangeRateSpread = auditor.ExchangeRateQuotes()
+	}
+
+	return report
+}
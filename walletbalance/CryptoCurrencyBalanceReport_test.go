@@ -1,4 +1,4 @@
-package main
+package walletbalance
 
 import (
 	"errors"
@@ -14,13 +14,13 @@ type MockCryptoCurrencyInfoFetcher struct {
 }
 
 func (m *MockCryptoCurrencyInfoFetcher) FetchBalance(addresses []string, apiKey string, balance *float64, err *error, done *sync.WaitGroup) {
-	m.Called(addresses, apiKey, balance, err, done)
+	m.Called(addresses, apiKey, balance, err)
 	done.Done()
 	return
 }
 
 func (m *MockCryptoCurrencyInfoFetcher) FetchExchangeRate(apiKey string, targetCurrency string, exchangeRate *float64, err *error, done *sync.WaitGroup) {
-	m.Called(apiKey, targetCurrency, exchangeRate, err, done)
+	m.Called(apiKey, targetCurrency, exchangeRate, err)
 	done.Done()
 	return
 }
@@ -28,36 +28,36 @@ func (m *MockCryptoCurrencyInfoFetcher) FetchExchangeRate(apiKey string, targetC
 func TestFetchInfoForCryptoCurrency(t *testing.T) {
 	cases := []struct {
 		name                    string
-		symbol                  cryptoCurrencyTickerSymbol
+		symbol                  CryptoCurrencyTickerSymbol
 		apiKey                  string
 		addresses               []string
 		returnedBalanceErr      error
 		returnedExchangeRateErr error
 		returnedBalance         float64
 		returnedUsdExchangeRate float64
-		returnedErrMessage      string
+		expectedPriced          bool
 	}{
-		{"BTC", btc, "random_api_key#1", []string{"a", "b"}, nil, nil, 1000., 99., ""},
-		{"ETH", eth, "random_api_key#2", []string{"d"}, nil, nil, 50., 3., ""},
-		{"balance error is propagated", eth, "random_api_key#2", []string{"d"}, errors.New("balance retrieval error"), nil, 0., 4., "Balance retrieval error"},
-		{"exchange rate error is propagated", eth, "random_api_key#2", []string{"d"}, nil, errors.New("exchange rate retrieval error"), 0., 4., "Exchange rate retrieval error"},
+		{"BTC", BTC, "random_api_key#1", []string{"a", "b"}, nil, nil, 1000., 99., true},
+		{"ETH", ETH, "random_api_key#2", []string{"d"}, nil, nil, 50., 3., true},
+		{"balance error is propagated", ETH, "random_api_key#2", []string{"d"}, errors.New("balance retrieval error"), nil, 0., 4., false},
+		{"exchange rate error is propagated", ETH, "random_api_key#2", []string{"d"}, nil, errors.New("exchange rate retrieval error"), 0., 4., false},
+		{"balance is kept when the exchange rate fails", LTC, "", []string{"e"}, nil, errors.New("exchange rate retrieval error"), 12.5, 0., false},
+		{"both errors are kept", LTC, "", []string{"e"}, errors.New("balance retrieval error"), errors.New("exchange rate retrieval error"), 0., 0., false},
 	}
 
 	for _, testCase := range cases {
 		done := make(chan *CryptoCurrencyBalanceReport, 2)
 
-		config := &cryptoBalanceCheckerConfig{testCase.symbol, testCase.addresses, testCase.apiKey}
+		config := &Config{Symbol: testCase.symbol, Addresses: testCase.addresses, APIKey: testCase.apiKey}
 
 		infoFetcherMock := new(MockCryptoCurrencyInfoFetcher)
-		infoFetcherMock.On("FetchBalance", testCase.addresses, testCase.apiKey, mock.AnythingOfType("*float64"), mock.AnythingOfType("*error"), mock.AnythingOfType("chan<- bool")).Once().Run(func(args mock.Arguments) {
+		infoFetcherMock.On("FetchBalance", testCase.addresses, testCase.apiKey, mock.AnythingOfType("*float64"), mock.AnythingOfType("*error")).Once().Run(func(args mock.Arguments) {
 			*(args.Get(2).(*float64)) = testCase.returnedBalance
 			*(args.Get(3).(*error)) = testCase.returnedBalanceErr
-			args.Get(4).(chan<- bool) <- true
 		})
-		infoFetcherMock.On("FetchExchangeRate", testCase.apiKey, "usd", mock.AnythingOfType("*float64"), mock.AnythingOfType("*error"), mock.AnythingOfType("chan<- bool")).Once().Run(func(args mock.Arguments) {
+		infoFetcherMock.On("FetchExchangeRate", testCase.apiKey, "usd", mock.AnythingOfType("*float64"), mock.AnythingOfType("*error")).Once().Run(func(args mock.Arguments) {
 			*(args.Get(2).(*float64)) = testCase.returnedUsdExchangeRate
 			*(args.Get(3).(*error)) = testCase.returnedExchangeRateErr
-			args.Get(4).(chan<- bool) <- true
 		})
 
 		FetchInfoForCryptoCurrency(config, infoFetcherMock, done)
@@ -69,10 +69,27 @@ func TestFetchInfoForCryptoCurrency(t *testing.T) {
 		require.Equal(t, testCase.symbol, report.Symbol)
 		require.Equalf(t, testCase.returnedBalance, report.Balance, "Balance reported (%f) does not matched expected value (%f)", report.Balance, testCase.returnedBalance)
 		require.Equalf(t, testCase.returnedUsdExchangeRate, report.UsdExchangeRate, "Exchange rate reported (%f) does not matched expected value (%f)", report.UsdExchangeRate, testCase.returnedUsdExchangeRate)
-		if testCase.returnedErrMessage == "" {
-			require.Nil(t, report.Error)
+		require.Equal(t, testCase.expectedPriced, report.Priced(), testCase.name)
+		if testCase.returnedBalanceErr == nil {
+			require.Nil(t, report.BalanceError, testCase.name)
 		} else {
-			require.EqualErrorf(t, report.Error, testCase.returnedErrMessage, "Error reported (%s) does not matched expected value (%s)", report.Error, testCase.returnedErrMessage)
+			require.EqualError(t, report.BalanceError, testCase.returnedBalanceErr.Error(), testCase.name)
+		}
+		if testCase.returnedExchangeRateErr == nil {
+			require.Nil(t, report.ExchangeRateError, testCase.name)
+		} else {
+			require.EqualError(t, report.ExchangeRateError, testCase.returnedExchangeRateErr.Error(), testCase.name)
 		}
 	}
 }
+
+func TestFetchInfoForCryptoCurrencyRejectsUnsupportedMinConfirmations(t *testing.T) {
+	done := make(chan *CryptoCurrencyBalanceReport, 1)
+	config := &Config{Symbol: BTC, Addresses: []string{"a"}, MinConfirmations: 3}
+
+	FetchInfoForCryptoCurrency(config, &fakeInfoFetcher{balance: 1, exchangeRate: 10000}, done)
+
+	report := <-done
+	require.EqualError(t, report.BalanceError, "confirmed balances are not supported by *walletbalance.fakeInfoFetcher")
+	require.NoError(t, report.ExchangeRateError)
+}
@@ -0,0 +1,144 @@
+package walletbalance
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+)
+
+// explorerPriceProvider denotes that the exchange rate is fetched from the same provider as the balance
+const explorerPriceProvider = "explorer"
+
+// defaultProviders maps CryptoCurrencyTickerSymbol values to the provider used when a config entry doesn't specify one
+var defaultProviders map[CryptoCurrencyTickerSymbol]string
+
+// defaultPriceProviders maps providers which don't report exchange rates (such as full nodes) to the price provider used when a config entry doesn't specify one
+var defaultPriceProviders = map[string]string{
+	fetchers.BitcoindProvider:    fetchers.CoinGeckoPriceProviderName,
+	fetchers.EthereumRPCProvider: fetchers.CoinGeckoPriceProviderName,
+	fetchers.ElectrumProvider:    fetchers.CoinGeckoPriceProviderName,
+	fetchers.EsploraProvider:     fetchers.CoinGeckoPriceProviderName,
+}
+
+func init() {
+	defaultProviders = map[CryptoCurrencyTickerSymbol]string{
+		BTC:   fetchers.BlockchainInfoProvider,
+		ETH:   fetchers.EtherscanProvider,
+		LTC:   fetchers.CryptoidProvider,
+		DASH:  fetchers.CryptoidProvider,
+		UNO:   fetchers.CryptoidProvider,
+		BCH:   fetchers.BlockchairProvider,
+		MATIC: fetchers.EtherscanProvider,
+		BNB:   fetchers.EtherscanProvider,
+	}
+}
+
+// CryptoCurrencyInfoFetcherCreator defines the interface for a factory that creates a fetchers.CryptoCurrencyInfoFetcher based on a config entry
+type CryptoCurrencyInfoFetcherCreator interface {
+	Create(config *Config) (fetchers.CryptoCurrencyInfoFetcher, error)
+}
+
+// CryptoCurrencyInfoHTTPFetcherCreator implements a factory that creates a fetchers.CryptoCurrencyInfoFetcher based on a config entry and an HTTP client
+type CryptoCurrencyInfoHTTPFetcherCreator struct {
+	client fetchers.HTTPClient
+
+	priceBatchesMutex sync.Mutex
+	priceBatches      map[string]*fetchers.BatchedPriceFetcher
+}
+
+// NewCryptoCurrencyInfoHTTPFetcherCreator creates a CryptoCurrencyInfoHTTPFetcherCreator factory object
+func NewCryptoCurrencyInfoHTTPFetcherCreator(client fetchers.HTTPClient) *CryptoCurrencyInfoHTTPFetcherCreator {
+	return &CryptoCurrencyInfoHTTPFetcherCreator{client: client, priceBatches: make(map[string]*fetchers.BatchedPriceFetcher)}
+}
+
+// Create creates a fetchers.CryptoCurrencyInfoFetcher instance for the given config entry from the provider registered under the entry's provider name,
+// attached to the HTTP client specified in CryptoCurrencyInfoHttpFetcherCreator. Exchange rates of entries sharing a price provider are fetched in a single batch,
+// so all entries should be created before any information is fetched.
+func (creator *CryptoCurrencyInfoHTTPFetcherCreator) Create(config *Config) (infoFetcher fetchers.CryptoCurrencyInfoFetcher, err error) {
+	var balanceFetcher fetchers.CryptoCurrencyBalanceFetcher
+	var exchangeRateFetcher fetchers.CryptoCurrencyExchangeRateFetcher
+	var providerFetcher fetchers.CryptoCurrencyInfoFetcher
+
+	if config.IsManual() {
+		balanceFetcher = fetchers.NewFixedBalanceFetcher(*config.ManualBalance)
+	}
+
+	if len(config.PriceProviders) > 0 {
+		sources := make([]*fetchers.ExchangeRateSource, len(config.PriceProviders))
+		for index, priceProvider := range config.PriceProviders {
+			source := &fetchers.ExchangeRateSource{Name: priceProvider}
+			if priceProvider == explorerPriceProvider {
+				if providerFetcher == nil {
+					if providerFetcher, err = creator.createProviderFetcher(config); err != nil {
+						return
+					}
+				}
+				source.Fetcher = providerFetcher
+			} else if source.Fetcher, err = creator.createExchangeRateFetcher(config, priceProvider); err != nil {
+				return
+			}
+			sources[index] = source
+		}
+		exchangeRateFetcher = fetchers.NewMedianExchangeRateFetcher(sources, config.MaxPriceDeviation)
+	} else if priceProvider := config.priceProvider(); priceProvider != "" && priceProvider != explorerPriceProvider {
+		if exchangeRateFetcher, err = creator.createExchangeRateFetcher(config, priceProvider); err != nil {
+			return
+		}
+	}
+
+	if balanceFetcher == nil || exchangeRateFetcher == nil {
+		if providerFetcher == nil {
+			if providerFetcher, err = creator.createProviderFetcher(config); err != nil {
+				return
+			}
+		}
+
+		if balanceFetcher == nil {
+			balanceFetcher = providerFetcher
+		}
+		if exchangeRateFetcher == nil {
+			exchangeRateFetcher = providerFetcher
+		}
+	}
+
+	// Rather than being ignored, min_confirmations is rejected for balance sources which can't tell pending funds apart
+	if _, ok := balanceFetcher.(fetchers.CryptoCurrencyDetailedBalanceFetcher); config.MinConfirmations > 0 && !ok {
+		return nil, fmt.Errorf("min_confirmations is not supported by the %s provider", config.providerName())
+	}
+
+	if providerFetcher != nil && balanceFetcher == providerFetcher && exchangeRateFetcher == providerFetcher {
+		return providerFetcher, nil
+	}
+
+	infoFetcher = fetchers.NewCompositeInfoFetcher(balanceFetcher, exchangeRateFetcher)
+
+	return
+}
+
+func (creator *CryptoCurrencyInfoHTTPFetcherCreator) createProviderFetcher(config *Config) (fetchers.CryptoCurrencyInfoFetcher, error) {
+	provider := config.providerName()
+	if provider == "" {
+		return nil, fmt.Errorf("unknown crypto-currency %s, please specify a provider", config.Symbol)
+	}
+
+	return fetchers.NewInfoFetcher(provider, string(config.Symbol), creator.client, config.ProviderOptions)
+}
+
+func (creator *CryptoCurrencyInfoHTTPFetcherCreator) createExchangeRateFetcher(config *Config, priceProviderName string) (fetchers.CryptoCurrencyExchangeRateFetcher, error) {
+	creator.priceBatchesMutex.Lock()
+	defer creator.priceBatchesMutex.Unlock()
+
+	batch, ok := creator.priceBatches[priceProviderName]
+	if !ok {
+		priceProvider, err := fetchers.NewPriceProvider(priceProviderName, creator.client)
+		if err != nil {
+			return nil, err
+		}
+
+		batch = fetchers.NewBatchedPriceFetcher(priceProvider)
+		creator.priceBatches[priceProviderName] = batch
+	}
+
+	return batch.ExchangeRateFetcher(string(config.Symbol), config.PriceID), nil
+}
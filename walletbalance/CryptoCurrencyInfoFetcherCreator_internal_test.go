@@ -0,0 +1,32 @@
+package walletbalance
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateRejectsUnsupportedMinConfirmations(t *testing.T) {
+	cases := []struct {
+		name        string
+		config      *Config
+		expectedErr string
+	}{
+		{"node with a price provider", &Config{Symbol: BTC, Provider: "bitcoind", ProviderOptions: json.RawMessage(`{"url": "http://127.0.0.1:8332"}`), MinConfirmations: 3},
+			"min_confirmations is not supported by the bitcoind provider"},
+		{"node without min_confirmations", &Config{Symbol: BTC, Provider: "bitcoind", ProviderOptions: json.RawMessage(`{"url": "http://127.0.0.1:8332"}`)}, ""},
+		{"explorer", &Config{Symbol: BTC, MinConfirmations: 3}, ""},
+		{"electrum", &Config{Symbol: BTC, Provider: "electrum", ProviderOptions: json.RawMessage(`{"server": "127.0.0.1:50001"}`), MinConfirmations: 1}, ""},
+	}
+
+	for _, testCase := range cases {
+		_, err := NewCryptoCurrencyInfoHTTPFetcherCreator(&http.Client{}).Create(testCase.config)
+		if testCase.expectedErr != "" {
+			require.EqualError(t, err, testCase.expectedErr, testCase.name)
+		} else {
+			require.NoError(t, err, testCase.name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package walletbalance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/PombeirP/wallet-balance/lots"
+)
+
+// TaxableDisposal is a disposal of one of the configured crypto-currencies
+type TaxableDisposal struct {
+	Symbol CryptoCurrencyTickerSymbol
+	*lots.Disposal
+}
+
+// fetchDisposals replays the history of every config entry and returns the disposals that happened in [start, end), in chronological order.
+// The entries of the same crypto-currency share their lots, so that moving funds between two configured wallets keeps their cost basis.
+// Currencies with a disposal exceeding the lots acquired before it are reported as errors, since their cost basis and holding periods can't be determined.
+// Errors are keyed by the index of their config entry in `currenciesConfig`
+func fetchDisposals(currenciesConfig []*Config, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator, method lots.Method, start, end time.Time) (disposals []*TaxableDisposal, errs map[int]error) {
+	errs = make(map[int]error)
+
+	var symbols []CryptoCurrencyTickerSymbol
+	entriesBySymbol := make(map[CryptoCurrencyTickerSymbol][]int)
+	for index, currencyConfig := range currenciesConfig {
+		if _, ok := entriesBySymbol[currencyConfig.Symbol]; !ok {
+			symbols = append(symbols, currencyConfig.Symbol)
+		}
+		entriesBySymbol[currencyConfig.Symbol] = append(entriesBySymbol[currencyConfig.Symbol], index)
+	}
+
+	var mutex sync.Mutex
+	ledgersBuilt := sync.WaitGroup{}
+	for _, symbol := range symbols {
+		ledgersBuilt.Add(1)
+		go func(symbol CryptoCurrencyTickerSymbol, indices []int) {
+			defer ledgersBuilt.Done()
+
+			ledger, ledgerErrs := buildSymbolLedger(currenciesConfig, indices, currencyInfoFetcherCreator, method)
+			if len(ledgerErrs) == 0 {
+				if err := checkDisposalsMatched(ledger.Disposals(), symbol); err != nil {
+					ledgerErrs = map[int]error{indices[0]: err}
+				}
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if len(ledgerErrs) > 0 {
+				for index, err := range ledgerErrs {
+					errs[index] = err
+				}
+				return
+			}
+			for _, disposal := range ledger.Disposals() {
+				if !disposal.Disposed.Before(start) && disposal.Disposed.Before(end) {
+					disposals = append(disposals, &TaxableDisposal{symbol, disposal})
+				}
+			}
+		}(symbol, entriesBySymbol[symbol])
+	}
+	ledgersBuilt.Wait()
+
+	sort.SliceStable(disposals, func(i, j int) bool {
+		return disposals[i].Disposed.Before(disposals[j].Disposed)
+	})
+
+	return
+}
+
+// buildSymbolLedger replays the lots and transaction histories of the config entries at `indices`, all of the same crypto-currency, into a single lots.Ledger.
+// A transaction seen by several entries, such as a transfer between two configured wallets, is replayed once with the movements of all of them,
+// so that only its fee is disposed of. Prices are looked up with the fetcher of the first entry.
+// Errors are keyed by the index of the entry they relate to, or of the first entry if they relate to all of them
+func buildSymbolLedger(currenciesConfig []*Config, indices []int, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator, method lots.Method) (*lots.Ledger, map[int]error) {
+	errs := make(map[int]error)
+	first := currenciesConfig[indices[0]]
+
+	ledgerMethods := make(map[lots.Method]bool)
+	var priceFetcher fetchers.CryptoCurrencyInfoFetcher
+	var events []*ledgerEvent
+	var transactionIDs []string
+	mergedTransactions := make(map[string]*fetchers.Transaction)
+	prices := make(map[string]float64)
+	for _, index := range indices {
+		config := currenciesConfig[index]
+
+		entryMethod, err := ledgerMethod(config, method)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+		ledgerMethods[entryMethod] = true
+
+		infoFetcher, err := currencyInfoFetcherCreator.Create(withHistoricalPriceProvider(config))
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+		if priceFetcher == nil {
+			priceFetcher = infoFetcher
+		}
+
+		transactions, err := fetchTransactionHistory(config, infoFetcher)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+
+		events = append(events, ledgerEvents(config, nil)...)
+		for _, tx := range transactions {
+			if _, ok := mergedTransactions[tx.TxID]; !ok {
+				transactionIDs = append(transactionIDs, tx.TxID)
+			}
+			mergeTransaction(mergedTransactions, tx)
+		}
+		if config.CostBasis != nil {
+			for txID, price := range config.CostBasis.Prices {
+				prices[txID] = price
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if len(ledgerMethods) > 1 {
+		return nil, map[int]error{indices[0]: fmt.Errorf("the %s entries use different cost_basis methods, which must match since they share their lots", first.Symbol)}
+	}
+
+	for _, txID := range transactionIDs {
+		tx := mergedTransactions[txID]
+		events = append(events, &ledgerEvent{tx.Time, tx.NetAmount(), prices[txID]})
+	}
+
+	var sharedMethod lots.Method
+	for entryMethod := range ledgerMethods {
+		sharedMethod = entryMethod
+	}
+	ledger, err := replayLedger(first, priceFetcher, events, sharedMethod)
+	if err != nil {
+		return nil, map[int]error{indices[0]: err}
+	}
+
+	return ledger, nil
+}
+
+// mergeTransaction adds `tx` to `transactions`, keyed by transaction id. The movements of a transaction seen before are merged into it,
+// skipping the addresses it already has a movement for
+func mergeTransaction(transactions map[string]*fetchers.Transaction, tx *fetchers.Transaction) {
+	merged, ok := transactions[tx.TxID]
+	if !ok {
+		copied := *tx
+		copied.Movements = append([]*fetchers.AddressMovement(nil), tx.Movements...)
+		transactions[tx.TxID] = &copied
+		return
+	}
+
+	for _, movement := range tx.Movements {
+		if !hasMovement(merged, movement.Address) {
+			merged.Movements = append(merged.Movements, movement)
+		}
+	}
+}
+
+// hasMovement returns true if `tx` has a movement for `address`
+func hasMovement(tx *fetchers.Transaction, address string) bool {
+	for _, movement := range tx.Movements {
+		if movement.Address == address {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkDisposalsMatched returns an error if any of the disposals of `symbol` couldn't be matched to an acquisition, which means the transaction history or lots are incomplete
+func checkDisposalsMatched(disposals []*lots.Disposal, symbol CryptoCurrencyTickerSymbol) error {
+	for _, disposal := range disposals {
+		if disposal.Unmatched && disposal.Amount > holdingsTolerance {
+			return fmt.Errorf("%.8f %s disposed of on %s exceed the acquisitions in the transaction history and lots", disposal.Amount, symbol, disposal.Disposed.UTC().Format(DateLayout))
+		}
+	}
+
+	return nil
+}
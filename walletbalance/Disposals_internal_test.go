@@ -0,0 +1,92 @@
+package walletbalance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchDisposalsRejectsUnmatchedDisposals(t *testing.T) {
+	movement := func(txID string, at time.Time, in, out float64) *fetchers.Transaction {
+		return &fetchers.Transaction{TxID: txID, Time: at, Movements: []*fetchers.AddressMovement{{Address: "a", In: in, Out: out}}}
+	}
+	acquired := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	disposed := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	creator := fakeHistoryFetcherCreator{
+		// The acquisition of the ETH sold was left out of the history
+		BTC: {transactions: []*fetchers.Transaction{movement("tx2", disposed, 0, 0.5), movement("tx1", acquired, 1, 0)}, price: 1000},
+		ETH: {transactions: []*fetchers.Transaction{movement("tx4", disposed, 0, 2), movement("tx3", acquired, 1, 0)}, price: 100},
+	}
+	configs := []*Config{{Symbol: BTC, Addresses: []string{"a"}}, {Symbol: ETH, Addresses: []string{"a"}}}
+
+	disposals, errs := fetchDisposals(configs, creator, "", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	require.Len(t, disposals, 1)
+	require.Equal(t, BTC, disposals[0].Symbol)
+	require.Equal(t, 0.5, disposals[0].Amount)
+	require.Equal(t, 500., disposals[0].CostBasis)
+
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[1], "1.00000000 ETH disposed of on 2017-06-01 exceed the acquisitions in the transaction history and lots")
+}
+
+// fakeWalletFetcherCreator hands out the fake fetcher registered for the first address of each entry
+type fakeWalletFetcherCreator map[string]*fakeHistoryFetcher
+
+func (creator fakeWalletFetcherCreator) Create(config *Config) (fetchers.CryptoCurrencyInfoFetcher, error) {
+	return creator[config.Addresses[0]], nil
+}
+
+func TestFetchDisposalsKeepsCostBasisOfTransfersBetweenEntries(t *testing.T) {
+	acquired := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	transferred := time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC)
+	sold := time.Date(2017, 6, 1, 0, 0, 0, 0, time.UTC)
+	acquisition := &fetchers.Transaction{TxID: "tx1", Time: acquired, Movements: []*fetchers.AddressMovement{{Address: "a", In: 1}}}
+	sale := &fetchers.Transaction{TxID: "tx3", Time: sold, Movements: []*fetchers.AddressMovement{{Address: "b", Out: 0.5}}}
+	creator := fakeWalletFetcherCreator{
+		// Each wallet only sees its own side of the transfer, whose fee is paid by the sender
+		"a": {transactions: []*fetchers.Transaction{
+			{TxID: "tx2", Time: transferred, Fee: 0.0001, Movements: []*fetchers.AddressMovement{{Address: "a", Out: 1}}},
+			acquisition,
+		}, price: 1000},
+		"b": {transactions: []*fetchers.Transaction{
+			sale,
+			{TxID: "tx2", Time: transferred, Fee: 0.0001, Movements: []*fetchers.AddressMovement{{Address: "b", In: 0.9999}}},
+		}, price: 1000},
+	}
+	configs := []*Config{
+		{Symbol: BTC, Addresses: []string{"a"}, CostBasis: &CostBasisConfig{Prices: map[string]float64{"tx1": 100}}},
+		{Symbol: BTC, Addresses: []string{"b"}},
+	}
+
+	disposals, errs := fetchDisposals(configs, creator, "", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	require.Empty(t, errs)
+	require.Len(t, disposals, 2)
+
+	// Only the fee of the transfer is disposed of, and the coins sold afterwards keep the cost basis of their acquisition
+	require.Equal(t, transferred, disposals[0].Disposed)
+	require.InDelta(t, 0.0001, disposals[0].Amount, 1e-12)
+	require.Equal(t, sold, disposals[1].Disposed)
+	require.Equal(t, 0.5, disposals[1].Amount)
+	require.InDelta(t, 50., disposals[1].CostBasis, 1e-9)
+	require.InDelta(t, 500., disposals[1].Proceeds, 1e-9)
+}
+
+func TestFetchDisposalsRejectsMismatchedMethods(t *testing.T) {
+	creator := fakeWalletFetcherCreator{"a": {price: 1000}, "b": {price: 1000}}
+	configs := []*Config{
+		{Symbol: BTC, Addresses: []string{"a"}, CostBasis: &CostBasisConfig{Method: "lifo"}},
+		{Symbol: BTC, Addresses: []string{"b"}},
+	}
+
+	_, errs := fetchDisposals(configs, creator, "", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "the BTC entries use different cost_basis methods, which must match since they share their lots")
+
+	// Overriding the method of every entry resolves the conflict
+	_, errs = fetchDisposals(configs, creator, "fifo", time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC))
+	require.Empty(t, errs)
+}
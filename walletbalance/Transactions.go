@@ -0,0 +1,69 @@
+package walletbalance
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/PombeirP/wallet-balance/fetchers"
+)
+
+// SymbolTransaction is a transaction of one of the configured crypto-currencies
+type SymbolTransaction struct {
+	Symbol CryptoCurrencyTickerSymbol
+	// Label is the name of the config entry the transaction belongs to, as returned by Config.Label
+	Label string
+	*fetchers.Transaction
+}
+
+// fetchTransactions retrieves the transactions of all configured addresses, most recent first,
+// along with the errors encountered keyed by the index of their config entry in `currenciesConfig`
+func fetchTransactions(currenciesConfig []*Config, currencyInfoFetcherCreator CryptoCurrencyInfoFetcherCreator) (transactions []*SymbolTransaction, errs map[int]error) {
+	errs = make(map[int]error)
+
+	var mutex sync.Mutex
+	transactionsFetched := sync.WaitGroup{}
+	for index, currencyConfig := range currenciesConfig {
+		if currencyConfig.IsManual() {
+			continue
+		}
+
+		infoFetcher, err := currencyInfoFetcherCreator.Create(currencyConfig)
+		if err != nil {
+			errs[index] = err
+			continue
+		}
+		transactionFetcher, ok := infoFetcher.(fetchers.CryptoCurrencyTransactionFetcher)
+		if !ok {
+			errs[index] = fmt.Errorf("transaction history is not supported by %T", infoFetcher)
+			continue
+		}
+
+		transactionsFetched.Add(1)
+		go func(index int, config *Config) {
+			var currencyTransactions []*fetchers.Transaction
+			var err error
+			fetched := sync.WaitGroup{}
+			fetched.Add(1)
+			transactionFetcher.FetchTransactions(config.Addresses, config.APIKey, &currencyTransactions, &err, &fetched)
+			fetched.Wait()
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs[index] = err
+			}
+			for _, tx := range currencyTransactions {
+				transactions = append(transactions, &SymbolTransaction{config.Symbol, config.Label(), tx})
+			}
+			transactionsFetched.Done()
+		}(index, currencyConfig)
+	}
+	transactionsFetched.Wait()
+
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Time.After(transactions[j].Time)
+	})
+
+	return
+}
@@ -0,0 +1,20 @@
+package walletbalance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchTransactionsReportsErrorsPerEntry(t *testing.T) {
+	creator := fakeInfoFetcherCreator{BTC: {balance: 1, exchangeRate: 10000}}
+	configs := []*Config{{Symbol: "XYZ", Addresses: []string{"x"}}, {Symbol: BTC, Addresses: []string{"a"}}, {Symbol: "XYZ", Addresses: []string{"y"}}}
+
+	transactions, errs := fetchTransactions(configs, creator)
+
+	require.Empty(t, transactions)
+	require.Len(t, errs, 3)
+	require.EqualError(t, errs[0], "unknown crypto-currency XYZ, please specify a provider")
+	require.EqualError(t, errs[1], "transaction history is not supported by *walletbalance.fakeInfoFetcher")
+	require.EqualError(t, errs[2], "unknown crypto-currency XYZ, please specify a provider")
+}
@@ -0,0 +1,13 @@
+// Package walletbalance fetches the balances, transactions and realized gains of crypto-currency holdings from the providers configured for them.
+//
+// Load the holdings with LoadConfigFromJSONFile, then fetch their reports with a Client:
+//
+//	configs, err := walletbalance.LoadConfigFromJSONFile("config.json")
+//	if err != nil {
+//		return err
+//	}
+//	client := walletbalance.NewClient(walletbalance.WithConcurrency(5))
+//	for _, report := range client.FetchReports(ctx, configs) {
+//		fmt.Println(report.Symbol, report.Balance, report.UsdBalance())
+//	}
+package walletbalance